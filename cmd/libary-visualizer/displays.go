@@ -0,0 +1,282 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"errors"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
+	"image"
+	"math/rand"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/compose"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/palette"
+	"vg-cover-screen-saver-go/internal/app/selection"
+	"vg-cover-screen-saver-go/internal/app/slideshow"
+	"vg-cover-screen-saver-go/internal/app/store"
+)
+
+const defaultPlaceCommand = "wmctrl -r {title} -e 0,{x},{y},{width},{height}"
+
+// displayArea is a part of the desktop in pixels, usually a monitor. Areas are configured in the X geometry format
+// xrandr uses, e.g. 1920x1080+0+0.
+type displayArea struct {
+	x      int
+	y      int
+	width  int
+	height int
+}
+
+// display is one window of the visualizer with a slideshow of its own: its own selection of games, its own controller
+// and its own game shown.
+type display struct {
+	title      string
+	window     fyne.Window
+	controller *slideshow.Controller
+	selection  selection.Strategy
+	launchArea *launchArea
+	// placed displays cover the configured area, spanning displays cover several areas with one hero behind the cover
+	placed   bool
+	area     displayArea
+	spanning bool
+	// currentGame points to the shown game in the game list, guarded by currentGameMutex
+	currentGame *domain.ClientGame
+	// pickedKey is the game picked for the display, guarded by pickMutex
+	pickedKey string
+}
+
+var (
+	displays  []*display
+	pickMutex sync.Mutex
+)
+
+var areaGeometry = regexp.MustCompile(`^(\d+)x(\d+)([+-]\d+)([+-]\d+)$`)
+
+// parseDisplayAreas reads a comma separated list of areas like 1920x1080+0+0,2560x1440+1920+0.
+func parseDisplayAreas(areaList string) ([]displayArea, error) {
+	var areas []displayArea
+	for _, geometry := range strings.Split(areaList, ",") {
+		geometry = strings.TrimSpace(geometry)
+		if geometry == "" {
+			continue
+		}
+		match := areaGeometry.FindStringSubmatch(geometry)
+		if match == nil {
+			return nil, errors.New("Invalid display area " + geometry + ", must be like 1920x1080+0+0")
+		}
+		width, _ := strconv.Atoi(match[1])
+		height, _ := strconv.Atoi(match[2])
+		x, _ := strconv.Atoi(match[3])
+		y, _ := strconv.Atoi(match[4])
+		areas = append(areas, displayArea{x: x, y: y, width: width, height: height})
+	}
+	return areas, nil
+}
+
+// spanArea is the smallest area covering all areas.
+func spanArea(areas []displayArea) displayArea {
+	left, top := areas[0].x, areas[0].y
+	right, bottom := areas[0].x+areas[0].width, areas[0].y+areas[0].height
+	for _, area := range areas[1:] {
+		if area.x < left {
+			left = area.x
+		}
+		if area.y < top {
+			top = area.y
+		}
+		if area.x+area.width > right {
+			right = area.x + area.width
+		}
+		if area.y+area.height > bottom {
+			bottom = area.y + area.height
+		}
+	}
+	return displayArea{x: left, y: top, width: right - left, height: bottom - top}
+}
+
+var monitorGeometry = regexp.MustCompile(` connected (primary )?(\d+x\d+[+-]\d+[+-]\d+)`)
+
+// monitorAreas returns the areas of the connected monitors as xrandr reports them, the primary monitor first.
+func monitorAreas() ([]displayArea, error) {
+	output, xrandrErr := exec.Command("xrandr", "--current").Output()
+	if xrandrErr != nil {
+		return nil, errors.New("Failed to run xrandr: " + xrandrErr.Error())
+	}
+	var geometries []string
+	for _, match := range monitorGeometry.FindAllStringSubmatch(string(output), -1) {
+		if match[1] != "" {
+			geometries = append([]string{match[2]}, geometries...)
+		} else {
+			geometries = append(geometries, match[2])
+		}
+	}
+	return parseDisplayAreas(strings.Join(geometries, ","))
+}
+
+// newDisplays opens a window per area configured in visualizer.displays, or one window when no areas are configured.
+// The screensaver covers all monitors, without areas configured it takes those of the connected monitors. In spanning
+// mode a single window covers all areas. The first display is steered by the slideshow controller, it is the one the
+// remote control and Home Assistant see.
+func newDisplays(visualizer fyne.App, gameStore *store.Store) ([]*display, error) {
+	areas, parseErr := parseDisplayAreas(mainProps.GetString("visualizer.displays", ""))
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if len(areas) == 0 && screensaverConfig != nil {
+		monitors, monitorsErr := monitorAreas()
+		if monitorsErr != nil {
+			warnLogger.Println("Failed to find the monitors, the screensaver covers one monitor: " + monitorsErr.Error())
+		} else if len(monitors) > 1 {
+			areas = monitors
+		}
+	}
+	strategy := mainProps.GetString("visualizer.selection.strategy", "random")
+	recencySize := mainProps.GetInt("visualizer.selection.recency.size", 20)
+	if len(areas) == 0 {
+		visualizerWindow := visualizer.NewWindow(visualizerTitle)
+		visualizerWindow.Resize(fyne.NewSize(1000, 600))
+		return []*display{{
+			title:      visualizerTitle,
+			window:     visualizerWindow,
+			controller: slideshowController,
+			selection:  selection.New(strategy, recencySize, gameStore),
+		}}, nil
+	}
+
+	spanning := len(areas) > 1 && mainProps.GetBool("visualizer.displays.span", false)
+	if spanning {
+		areas = []displayArea{spanArea(areas)}
+	}
+	var newDisplays []*display
+	for displayIndex, area := range areas {
+		newDisplay := &display{
+			// The number in the title lets the place command find the window whatever game it shows
+			title:      visualizerTitle + " [" + strconv.Itoa(displayIndex+1) + "]",
+			window:     newBorderlessWindow(visualizer),
+			controller: slideshowController,
+			placed:     true,
+			area:       area,
+			spanning:   spanning,
+		}
+		newDisplay.window.SetTitle(newDisplay.title)
+		newDisplay.window.Resize(fyne.NewSize(float32(area.width), float32(area.height)))
+		if displayIndex == 0 {
+			newDisplay.selection = selection.New(strategy, recencySize, gameStore)
+			newDisplay.window.SetMaster()
+		} else {
+			// Only the first display keeps its selection state, the displays would overwrite each other's otherwise
+			newDisplay.controller = slideshow.New(100)
+			newDisplay.selection = selection.New(strategy, recencySize, nil)
+			// The displays pause as one, whether paused by key, remote control or Home Assistant
+			slideshowController.AddFollower(newDisplay.controller)
+		}
+		newDisplays = append(newDisplays, newDisplay)
+	}
+	return newDisplays, nil
+}
+
+func newBorderlessWindow(visualizer fyne.App) fyne.Window {
+	if desktopDriver, isDesktop := visualizer.Driver().(desktop.Driver); isDesktop {
+		return desktopDriver.CreateSplashWindow()
+	}
+	return visualizer.NewWindow(visualizerTitle)
+}
+
+// placeWindow moves the window of the display onto its area with visualizer.displays.place.command, Fyne cannot place
+// windows itself. The command is tried until the window shows up.
+func placeWindow(gameDisplay *display) {
+	title, area := gameDisplay.title, gameDisplay.area
+	commandTemplate := mainProps.GetString("visualizer.displays.place.command", defaultPlaceCommand)
+	var args []string
+	for _, arg := range strings.Fields(commandTemplate) {
+		arg = strings.ReplaceAll(arg, "{title}", title)
+		arg = strings.ReplaceAll(arg, "{x}", strconv.Itoa(area.x))
+		arg = strings.ReplaceAll(arg, "{y}", strconv.Itoa(area.y))
+		arg = strings.ReplaceAll(arg, "{width}", strconv.Itoa(area.width))
+		arg = strings.ReplaceAll(arg, "{height}", strconv.Itoa(area.height))
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		return
+	}
+	var placeErr error
+	for attempt := 0; attempt < 20; attempt++ {
+		time.Sleep(500 * time.Millisecond)
+		output, runErr := exec.Command(args[0], args[1:]...).CombinedOutput()
+		if runErr == nil {
+			return
+		}
+		placeErr = errors.New(runErr.Error() + " " + strings.TrimSpace(string(output)))
+	}
+	warnLogger.Println("Failed to place window " + title + ": " + placeErr.Error())
+}
+
+// pickDisplayGame picks the next game of the display among the games no other display shows.
+func pickDisplayGame(games []domain.ClientGame, gameDisplay *display) (int, domain.ClientGame, bool) {
+	pickMutex.Lock()
+	defer pickMutex.Unlock()
+	shownKeys := make(map[string]bool)
+	for _, otherDisplay := range displays {
+		if otherDisplay != gameDisplay {
+			shownKeys[otherDisplay.pickedKey] = true
+		}
+	}
+	gameIndex, game, gameFound := pickGame(games, gameDisplay.selection, shownKeys)
+	if gameFound {
+		gameDisplay.pickedKey = game.Key()
+	}
+	return gameIndex, game, gameFound
+}
+
+// setPickedGame records a game asked for by key as the display's game, unless another display shows it. It returns
+// false when the game is taken.
+func setPickedGame(gameDisplay *display, game domain.ClientGame) bool {
+	pickMutex.Lock()
+	defer pickMutex.Unlock()
+	for _, otherDisplay := range displays {
+		if otherDisplay != gameDisplay && otherDisplay.pickedKey == game.Key() {
+			return false
+		}
+	}
+	gameDisplay.pickedKey = game.Key()
+	return true
+}
+
+// sendToDisplays sends the command to the slideshows of all displays.
+func sendToDisplays(command slideshow.Command) {
+	for _, gameDisplay := range displays {
+		gameDisplay.controller.Send(command)
+	}
+}
+
+// newSpanContent lays the cover over one wide hero stretched across all monitors, cropped rather than distorted. Games
+// without hero get the blurred background of the single window.
+func newSpanContent(game domain.ClientGame, gameDisplay *display, coverImage image.Image, overlayHideTime time.Time) (fyne.CanvasObject, domain.GameArtwork, error) {
+	var heroes []domain.GameArtwork
+	for _, artwork := range game.Heroes() {
+		if artwork.Type == domain.Hero {
+			heroes = append(heroes, artwork)
+		}
+	}
+	if len(heroes) == 0 {
+		return newBackgroundContent(game, coverImage, overlayHideTime)
+	}
+	hero := heroes[rand.Intn(len(heroes))]
+	heroImage, imgErr := imageCache.GetImage(hero.Url())
+	if imgErr != nil {
+		return nil, hero, errors.New("Failed to load value image for game " + game.Name + "URL: " + hero.Url() + " - " + imgErr.Error())
+	}
+	frame := newFrameImage(func(frameConfig compose.Config) image.Image {
+		return compose.CoverFrame(coverImage, compose.CropToFill(heroImage, frameConfig.Width, frameConfig.Height), frameConfig)
+	})
+	return container.New(layout.NewMaxLayout(), frame, newOverlay(game, palette.Of(hero, heroImage, paletteConfig.Size), overlayHideTime)), hero, nil
+}
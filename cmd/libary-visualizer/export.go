@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"vg-cover-screen-saver-go/internal/app/compose"
+	"vg-cover-screen-saver-go/internal/app/export"
+)
+
+// runExport renders a slideshow of games with transitions to an animated GIF or APNG, or to a numbered PNG frame
+// sequence for ffmpeg. Like the render command it needs no display.
+func runExport(args []string) {
+	exportConfig := export.LoadConfig(*mainProps)
+	renderConfig := compose.Config{
+		Width:  mainProps.GetInt("visualizer.export.width", 960),
+		Height: mainProps.GetInt("visualizer.export.height", 540),
+	}
+	exportFlags := flag.NewFlagSet("export", flag.ExitOnError)
+	filterExpression := exportFlags.String("filter", mainProps.GetString("visualizer.filter", "not hidden"),
+		"Only export the games matching the filter expression")
+	format := exportFlags.String("format", mainProps.GetString("visualizer.export.format", "gif"),
+		"Export format: gif, apng or png for a numbered frame sequence")
+	output := exportFlags.String("output", mainProps.GetString("visualizer.export.output", ""),
+		"Output file, or directory of the png frame sequence. Defaults to library.gif, library.png or export-frames")
+	displayMode := exportFlags.String("mode", mainProps.GetString("visualizer.display.mode", coverDisplayMode),
+		"Composition of the frames, cover or logo")
+	gameCount := exportFlags.Int("games", mainProps.GetInt("visualizer.export.games", 10), "Number of games to export")
+	exportFlags.IntVar(&renderConfig.Width, "width", renderConfig.Width, "Width of the frames in pixels")
+	exportFlags.IntVar(&renderConfig.Height, "height", renderConfig.Height, "Height of the frames in pixels")
+	exportFlags.IntVar(&exportConfig.FrameRate, "fps", exportConfig.FrameRate, "Frames per second")
+	exportFlags.Float64Var(&exportConfig.GameSeconds, "seconds", exportConfig.GameSeconds,
+		"Seconds each game is shown, including the transition to the next game")
+	exportFlags.StringVar(&exportConfig.Transition, "transition", exportConfig.Transition,
+		"Transition between games: fade, slide or none")
+	exportFlags.Float64Var(&exportConfig.TransitionSeconds, "transition-seconds", exportConfig.TransitionSeconds,
+		"Seconds the transition between games lasts")
+	exportFlags.Parse(args)
+
+	if *output == "" {
+		*output = map[string]string{"gif": "library.gif", "apng": "library.png", "png": "export-frames"}[*format]
+	}
+	exportErr := exportSlideshow(*filterExpression, *format, *output, *displayMode, *gameCount, renderConfig, exportConfig)
+	if exportErr != nil {
+		fmt.Println("Export failed! " + exportErr.Error())
+		errorLogger.Println("Failed to export slideshow: " + exportErr.Error())
+		os.Exit(1)
+	}
+	fmt.Println("Exported to " + *output)
+}
+
+func exportSlideshow(filterExpression string, format string, output string, displayMode string, gameCount int, renderConfig compose.Config, exportConfig export.Config) error {
+	games, gamesErr := loadRenderGames(filterExpression)
+	if gamesErr != nil {
+		return gamesErr
+	}
+	writer, writerErr := export.NewWriter(format, output, exportConfig.FrameRate)
+	if writerErr != nil {
+		return writerErr
+	}
+
+	var slides []image.Image
+	for _, game := range pickSequenceGames(games, gameCount) {
+		slide, composeErr := composeFrame(game, displayMode, -1, renderConfig)
+		if composeErr != nil {
+			warnLogger.Println(composeErr.Error())
+			continue
+		}
+		slides = append(slides, slide)
+		fmt.Println(game.Name)
+	}
+	writeErr := export.Write(slides, exportConfig, writer)
+	closeErr := writer.Close()
+	if writeErr != nil {
+		return writeErr
+	}
+	return closeErr
+}
@@ -0,0 +1,132 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+	"image/color"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/launcher"
+	"vg-cover-screen-saver-go/internal/app/store"
+)
+
+// launchArea is an invisible widget laid over the displayed game to catch the double click that launches it. In
+// screensaver mode it also hides the cursor and catches the mouse input waking the screensaver.
+type launchArea struct {
+	widget.BaseWidget
+	onLaunch func()
+}
+
+func newLaunchArea(onLaunch func()) *launchArea {
+	area := &launchArea{onLaunch: onLaunch}
+	area.ExtendBaseWidget(area)
+	return area
+}
+
+func (area *launchArea) Tapped(_ *fyne.PointEvent) {
+	wakeScreensaver()
+}
+
+func (area *launchArea) DoubleTapped(_ *fyne.PointEvent) {
+	if wakeScreensaver() {
+		return
+	}
+	area.onLaunch()
+}
+
+func (area *launchArea) MouseIn(event *desktop.MouseEvent) {
+	screensaverMouseMoved(event.AbsolutePosition)
+}
+
+func (area *launchArea) MouseMoved(event *desktop.MouseEvent) {
+	screensaverMouseMoved(event.AbsolutePosition)
+}
+
+func (area *launchArea) MouseOut() {
+}
+
+func (area *launchArea) Cursor() desktop.Cursor {
+	if screensaverCursorHidden() {
+		return desktop.HiddenCursor
+	}
+	return desktop.DefaultCursor
+}
+
+func (area *launchArea) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(canvas.NewRectangle(color.Transparent))
+}
+
+// setCurrentGame keeps a pointer to the game the display shows so launches update the game list the slideshow picks
+// from.
+func setCurrentGame(game *domain.ClientGame, gameDisplay *display) {
+	currentGameMutex.Lock()
+	defer currentGameMutex.Unlock()
+	gameDisplay.currentGame = game
+	gameDisplay.window.SetTitle(getGameTitle(gameDisplay.title, *game))
+}
+
+func getGameTitle(displayTitle string, game domain.ClientGame) string {
+	title := displayTitle + " - " + game.Name
+	if game.Favourite {
+		title += " ★"
+	}
+	if !game.LastLaunched.IsZero() {
+		title += " (last launched " + game.LastLaunched.Format("2006-01-02 15:04") + ")"
+	}
+	return title
+}
+
+func launchCurrentGame(gameStore *store.Store, game domain.ClientGame) {
+	launchErr := launcher.Launch(game, *mainProps)
+	if launchErr != nil {
+		errorLogger.Println("Failed to launch game " + game.Name + ": " + launchErr.Error())
+		return
+	}
+	updateCurrentGame(gameStore, game, func(storedGame *domain.ClientGame) {
+		storedGame.LastLaunched = time.Now()
+		storedGame.LaunchCount++
+	})
+}
+
+func toggleFavourite(gameStore *store.Store, game domain.ClientGame) {
+	updateCurrentGame(gameStore, game, func(storedGame *domain.ClientGame) {
+		storedGame.Favourite = !storedGame.Favourite
+	})
+}
+
+// toggleHidden hides the game from the slideshow, it is no longer picked once the library filter excludes hidden games.
+func toggleHidden(gameStore *store.Store, game domain.ClientGame) {
+	updateCurrentGame(gameStore, game, func(storedGame *domain.ClientGame) {
+		storedGame.Hidden = !storedGame.Hidden
+	})
+}
+
+// updateCurrentGame applies the update to the stored game and to the game list the slideshow picks from.
+func updateCurrentGame(gameStore *store.Store, game domain.ClientGame, update func(storedGame *domain.ClientGame)) {
+	// The stored game is re-read so the update does not overwrite data changed since the game list was loaded
+	storedGame, getGameErr := gameStore.GetGame(game.Key())
+	if getGameErr != nil {
+		warnLogger.Println("Failed to load game " + game.Name + " to update it: " + getGameErr.Error())
+		storedGame = game
+	}
+	update(&storedGame)
+	saveErr := gameStore.SaveGame(storedGame)
+	if saveErr != nil {
+		errorLogger.Println("Failed to update game " + game.Name + ": " + saveErr.Error())
+		return
+	}
+
+	currentGameMutex.Lock()
+	defer currentGameMutex.Unlock()
+	for _, gameDisplay := range displays {
+		if gameDisplay.currentGame != nil && gameDisplay.currentGame.Key() == storedGame.Key() {
+			*gameDisplay.currentGame = storedGame
+			gameDisplay.window.SetTitle(getGameTitle(gameDisplay.title, storedGame))
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build headless
+// +build headless
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Headless builds have the commands writing frames, exports, sites and wallpapers and the server, but not the desktop
+// visualizer, so they build and run without Fyne and X11.
+
+func runVisualizer(_ string) {
+	exitWithoutDesktop()
+}
+
+func runScreensaver(_ []string) {
+	exitWithoutDesktop()
+}
+
+func exitWithoutDesktop() {
+	fmt.Println("This build has no desktop visualizer, build it without the headless tag to show the slideshow")
+	os.Exit(1)
+}
@@ -1,26 +1,23 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/canvas"
-	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/layout"
-	"github.com/esimov/stackblur-go"
 	"github.com/magiconair/properties"
-	"github.com/tidwall/buntdb"
-	"image"
 	"log"
 	"math/rand"
-	"net/http"
 	"os"
-	"strconv"
 	"time"
+	"vg-cover-screen-saver-go/internal/app/artwork"
 	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/effects"
 	"vg-cover-screen-saver-go/internal/app/igdb"
+	"vg-cover-screen-saver-go/internal/app/imagecache"
+	"vg-cover-screen-saver-go/internal/app/localart"
+	"vg-cover-screen-saver-go/internal/app/palette"
 	"vg-cover-screen-saver-go/internal/app/steam"
+	"vg-cover-screen-saver-go/internal/app/steamgriddb"
+	"vg-cover-screen-saver-go/internal/app/store"
 )
 
 var (
@@ -29,6 +26,19 @@ var (
 	errorLogger *log.Logger
 	warnLogger  *log.Logger
 	infoLogger  *log.Logger
+
+	paletteConfig palette.Config
+	effectsConfig effects.Config
+	imageCache    *imagecache.Cache
+	effectsCache  *effects.Cache
+	localArtworks *localart.Library
+)
+
+const (
+	dbFile = "game_artwork.db"
+
+	coverDisplayMode = "cover"
+	logoDisplayMode  = "logo"
 )
 
 func init() {
@@ -39,174 +49,207 @@ func init() {
 	}
 	mainProps = properties.MustLoadFile("config.properties", properties.UTF8)
 	secretProps = properties.MustLoadFile("config-secret.properties", properties.UTF8)
+	paletteConfig = palette.LoadConfig(*mainProps)
+	imageCache = imagecache.New(
+		mainProps.GetString("visualizer.image.cache.directory", "image_cache"),
+		mainProps.GetInt("visualizer.image.cache.decoded", 32))
+	effectsConfig, err = effects.LoadConfig(*mainProps)
+	if err != nil {
+		log.Fatal(err)
+	}
+	effectsCache = effects.NewCache(imageCache, effectsConfig.CacheDirectory, effectsConfig.MaxProcessed,
+		effectsConfig.Background, effectsConfig.Cover)
 	errorLogger = log.New(logFile, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
 	warnLogger = log.New(logFile, "WARN: ", log.Ldate|log.Ltime|log.Lshortfile)
 	infoLogger = log.New(logFile, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
 }
 
 func main() {
-	runVisualizer()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "render":
+			runRender(os.Args[2:])
+			return
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "site":
+			runSite(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "wallpaper":
+			runWallpaper(os.Args[2:])
+			return
+		case "screensaver":
+			runScreensaver(os.Args[2:])
+			return
+		}
+	}
+	filterExpression := flag.String("filter", mainProps.GetString("visualizer.filter", "not hidden"),
+		"Only show the games matching the filter expression, e.g. source=Steam and playtime=0")
+	flag.Parse()
+	runVisualizer(*filterExpression)
 }
 
-func runVisualizer() {
-
-	visualizer := app.New()
-	visualizerWindow := visualizer.NewWindow("Game Library Visualizer")
-	visualizerWindow.Resize(fyne.NewSize(1000, 600))
-	// TODO loading screen
-
-	db, loadDbErr := loadDB()
-	if loadDbErr != nil {
-		errorLogger.Println("Failed to load DB: " + loadDbErr.Error())
-		return
+// loadLocalArtworks scans the local artwork directory when one is configured and tells if its artworks are in use.
+func loadLocalArtworks() bool {
+	localArtworkDirectory := mainProps.GetString("visualizer.artwork.local.directory", "")
+	if localArtworkDirectory == "" {
+		return false
 	}
-	ownedGames, getGamesErr := getOwnedGames(db)
-	if getGamesErr != nil {
-		errorLogger.Println("Failed to fetch owned games: " + getGamesErr.Error())
-		return
+	localArtworks = localart.New(localArtworkDirectory)
+	scanErr := localArtworks.Scan()
+	if scanErr != nil {
+		warnLogger.Println("Failed to scan local artworks in " + localArtworkDirectory + ": " + scanErr.Error())
+		localArtworks = nil
+		return false
 	}
+	return true
+}
 
-	// TODO thread loading of images incrementally in background while displaying already and newly added images
-	games, err := steam.GetGames(ownedGames, *secretProps)
-	if err == nil {
-		for _, gameData := range games {
-			fmt.Println(gameData.Name)
-			artworks, errorArtwork := igdb.GetGameArtworks(gameData, *secretProps)
-			if errorArtwork == nil {
-				gameData.Artworks = artworks
-				// update data
-				updateErr := db.Update(func(tx *buntdb.Tx) error {
-					bytes, marshErr := json.Marshal(gameData)
-					if marshErr != nil {
-						return marshErr
-					}
-					_, _, setErr := tx.Set(gameData.Source.String()+gameData.SourceId, string(bytes), nil)
-					return setErr
-				})
-				if updateErr != nil {
-					return
-				}
-			}
+// getArtworkProviders returns the providers of the artworks IGDB does not have, by the name used in
+// visualizer.artwork.providers. SteamGridDB is only used when an API key is configured.
+func getArtworkProviders() map[string]artwork.Provider {
+	artworkProviders := map[string]artwork.Provider{
+		domain.SteamProvider: steam.GetGameArtworks,
+	}
+	steamGridDbConfig := steamgriddb.LoadConfig(*mainProps, *secretProps)
+	if steamGridDbConfig.ApiKey != "" {
+		artworkProviders[domain.SteamGridDbProvider] = func(game domain.ClientGame) ([]domain.GameArtwork, error) {
+			return steamgriddb.GetGameArtworks(game, steamGridDbConfig)
 		}
 	}
+	return artworkProviders
+}
 
-	ownedGames, getGamesErr = getOwnedGames(db)
+// syncGames stores the newly owned games with their metadata and artworks and refreshes the playtime of the games
+// already stored.
+func syncGames(gameStore *store.Store) error {
+	ownedGames, getGamesErr := gameStore.GetGames()
 	if getGamesErr != nil {
-		errorLogger.Println("Failed to fetch owned games: " + getGamesErr.Error())
-		return
+		return getGamesErr
 	}
 
-	imageCoverTime, mainPropsError := strconv.Atoi(mainProps.MustGet("visualizer.image.time.seconds"))
-	if mainPropsError != nil {
-		errorLogger.Println("Failed to load value for visualizer.image.time.seconds. Must me numeric : " + mainPropsError.Error())
-		return
+	newGames, refreshedGames, err := steam.GetGames(ownedGames, *secretProps)
+	if err != nil {
+		return err
 	}
-
-	showGame(ownedGames, visualizerWindow)
-	go func() {
-		for range time.Tick(time.Second * time.Duration(imageCoverTime)) {
-			showGame(ownedGames, visualizerWindow)
+	providerPriority := artwork.ParsePriority(mainProps.GetString("visualizer.artwork.providers", "igdb,steamgriddb,steam"))
+	artworkProviders := getArtworkProviders()
+	backfillInterval := time.Hour * 24 * time.Duration(mainProps.GetInt("visualizer.artwork.backfill.days", 30))
+	for _, gameData := range refreshedGames {
+		// Games stored before artworks were deduplicated can hold the same artwork twice
+		gameData.Artworks = artwork.Dedupe(gameData.Artworks)
+		// Games without cover, mostly those IGDB could not match, get another chance with the other providers, as do
+		// games stored before the providers fetched the logos and heroes the logo mode shows. Many games have no logo
+		// or hero at any provider, they are only asked again once the backfill interval passed.
+		if isMissingArtworks(gameData) && time.Since(gameData.ArtworksFetched) >= backfillInterval {
+			gameData.Artworks = getArtworks(gameData, artworkProviders, providerPriority)
+			gameData.ArtworksFetched = time.Now()
 		}
-	}()
-
-	visualizerWindow.ShowAndRun()
-	defer func(db *buntdb.DB) {
-		dbCloseErr := db.Close()
-		if dbCloseErr != nil {
-			errorLogger.Println("Failed to close DB properly: " + dbCloseErr.Error())
+		// Games IGDB matched before its metadata was stored get it now, the filters and sorts would not see them otherwise
+		if gameData.IgdbId == 0 && hasIgdbArtworks(gameData) {
+			metadataGame, metadataErr := igdb.GetMetadata(gameData, *secretProps)
+			if metadataErr == nil {
+				gameData = metadataGame
+			} else {
+				warnLogger.Println("Failed to fetch IGDB metadata for game " + gameData.Name + ": " + metadataErr.Error())
+			}
+		}
+		// Games stored before palettes were extracted get the palette of their cover, the wall sorts covers by colour
+		addCoverPalette(&gameData)
+		updateErr := gameStore.SaveGame(gameData)
+		if updateErr != nil {
+			return updateErr
 		}
-	}(db)
-}
-
-func loadDB() (*buntdb.DB, error) {
-	db, err := buntdb.Open("game_artwork.db")
-	if err != nil {
-		fmt.Println(err)
 	}
-	err = db.CreateIndex("source", "*", buntdb.IndexJSON("source"))
-	if err != nil {
-		fmt.Println(err)
+	for _, gameData := range newGames {
+		fmt.Println(gameData.Name)
+		igdbGameData, errorIgdb := igdb.GetGame(gameData, *secretProps)
+		if errorIgdb == nil {
+			// IGDB replaces the artworks with its own, the Steam store artworks that came with the game are kept
+			igdbGameData.Artworks = artwork.Dedupe(append(igdbGameData.Artworks, gameData.Artworks...))
+			igdbGameData.Artworks = getArtworks(igdbGameData, artworkProviders, providerPriority)
+			igdbGameData.ArtworksFetched = time.Now()
+			// update data
+			updateErr := gameStore.SaveGame(igdbGameData)
+			if updateErr != nil {
+				return updateErr
+			}
+		}
 	}
-	err = db.CreateIndex("source_source_id", "*", buntdb.IndexJSON("source"), buntdb.IndexJSON("source-id"))
-	if err != nil {
-		fmt.Println(err)
+	return nil
+}
+
+// isMissingArtworks tells if the game has no cover, no logo or no hero, the other providers may have them.
+func isMissingArtworks(game domain.ClientGame) bool {
+	_, coverFound := game.Cover()
+	_, logoFound := game.Logo()
+	heroFound := false
+	for _, gameArtwork := range game.Artworks {
+		if gameArtwork.Type == domain.Hero {
+			heroFound = true
+		}
 	}
-	return db, err
+	return !coverFound || !logoFound || !heroFound
 }
 
-func getOwnedGames(db *buntdb.DB) ([]domain.ClientGame, error) {
-	ownedGames := make([]domain.ClientGame, 0)
-	err := db.View(func(tx *buntdb.Tx) error {
-		tx.Ascend("source", func(key, value string) bool {
-			game := domain.ClientGame{}
-			json.Unmarshal([]byte(value), &game)
-			ownedGames = append(ownedGames, game)
+// hasIgdbArtworks tells if IGDB matched the game when it was synced, unmatched games are not searched again.
+func hasIgdbArtworks(game domain.ClientGame) bool {
+	for _, gameArtwork := range game.Artworks {
+		if gameArtwork.GetProvider() == domain.IgdbProvider {
 			return true
-		})
-		return nil
-	})
-	return ownedGames, err
+		}
+	}
+	return false
 }
 
-func showGame(games []domain.ClientGame, visualizerWindow fyne.Window) {
-	game := games[rand.Intn(len(games))]
-	if len(game.Artworks) > 0 {
-		// TODO Error handling
-		artworkUrl := "https://images.igdb.com/igdb/image/upload/t_original/" + game.Artworks[0].ArtworkId + ".jpg"
-		imageResource, imgResErr := http.Get(artworkUrl)
-		if imgResErr != nil {
-			warnLogger.Println("Failed to load value image resource for game " + game.Name + "URL: " + artworkUrl + " - " + imgResErr.Error())
-			return
-		}
-		coverImage, _, imgErr := image.Decode(imageResource.Body)
-		if imgErr != nil {
-			warnLogger.Println("Failed to load value image for game " + game.Name + "URL: " + artworkUrl + " - " + imgErr.Error())
-			return
-		}
-		canvasCoverImage := canvas.NewImageFromImage(coverImage)
-		canvasCoverImage.FillMode = canvas.ImageFillContain
-		backgroundTransitionsNumber, mainPropsError := strconv.Atoi(mainProps.MustGet("visualizer.image.background.transitions"))
-		if mainPropsError != nil {
-			errorLogger.Println("Failed to load value for visualizer.image.background.transitions. Must me numeric : " + mainPropsError.Error())
-			return
+// getArtworks adds the artworks of the other providers to the IGDB artworks of the game and orders them by provider
+// priority, so the cover and backgrounds of the preferred provider are shown first.
+func getArtworks(game domain.ClientGame, artworkProviders map[string]artwork.Provider, providerPriority []string) []domain.GameArtwork {
+	artworks := game.Artworks
+	for _, providerName := range providerPriority {
+		provider, found := artworkProviders[providerName]
+		if !found {
+			continue
 		}
-		imageCoverTime, mainPropsError := strconv.Atoi(mainProps.MustGet("visualizer.image.time.seconds"))
-		if mainPropsError != nil {
-			errorLogger.Println("Failed to load value for visualizer.image.time.seconds. Must me numeric : " + mainPropsError.Error())
-			return
+		providerArtworks, providerErr := provider(game)
+		if providerErr != nil {
+			warnLogger.Println("Failed to fetch " + providerName + " artworks for game " + game.Name + ": " + providerErr.Error())
+			continue
 		}
-		sleepDuration := time.Millisecond * time.Duration(1000*(imageCoverTime/backgroundTransitionsNumber))
-		for i := 0; i <= backgroundTransitionsNumber; i++ {
-			showBackgroundGame(game, visualizerWindow, canvasCoverImage, sleepDuration)
+		artworks = append(artworks, providerArtworks...)
+	}
+	artworks = artwork.Dedupe(artwork.SortByProvider(artworks, providerPriority))
+
+	similarDistance := mainProps.GetInt("visualizer.artwork.dedupe.distance", 5)
+	if similarDistance >= 0 {
+		artworks = artwork.DedupeSimilar(artworks, imageCache, similarDistance)
+	}
+	for artworkIndex := range artworks {
+		paletteErr := palette.AddPalette(&artworks[artworkIndex], imageCache, paletteConfig.Size)
+		if paletteErr != nil {
+			warnLogger.Println("Failed to extract palette of " + artworks[artworkIndex].Url() + " for game " + game.Name + ": " + paletteErr.Error())
 		}
 	}
+	return artworks
 }
 
-func showBackgroundGame(game domain.ClientGame, visualizerWindow fyne.Window, canvasCoverImage *canvas.Image, sleepDuration time.Duration) {
-	windowLayout := layout.NewMaxLayout()
-	if len(game.Artworks) > 1 {
-		artworkUrl := "https://images.igdb.com/igdb/image/upload/t_original/" + game.Artworks[rand.Intn(len(game.Artworks)-1)+1].ArtworkId + ".jpg"
-		backgroundResource, imgResErr := http.Get(artworkUrl)
-		if imgResErr != nil {
-			warnLogger.Println("Failed to load value image resource for game " + game.Name + "URL: " + artworkUrl + " - " + imgResErr.Error())
-			return
-		}
-		backgroundImage, _, imgErr := image.Decode(backgroundResource.Body)
-		if imgErr != nil {
-			warnLogger.Println("Failed to load value image for game " + game.Name + "URL: " + artworkUrl + " - " + imgErr.Error())
-			return
-		}
-		blurredBackgroundImage, blurErr := stackblur.Run(backgroundImage, 20)
-		if blurErr != nil {
-			warnLogger.Println("Failed to blur image for game " + game.Name + "URL: " + artworkUrl + " - " + blurErr.Error())
+// addCoverPalette extracts the palette of the cover of the game when it has none yet.
+func addCoverPalette(game *domain.ClientGame) {
+	cover, coverFound := game.Cover()
+	if !coverFound || len(cover.Palette) > 0 {
+		return
+	}
+	for artworkIndex := range game.Artworks {
+		if game.Artworks[artworkIndex].Url() == cover.Url() {
+			paletteErr := palette.AddPalette(&game.Artworks[artworkIndex], imageCache, paletteConfig.Size)
+			if paletteErr != nil {
+				warnLogger.Println("Failed to extract palette of " + cover.Url() + " for game " + game.Name + ": " + paletteErr.Error())
+			}
 			return
 		}
-		canvasBackground := canvas.NewImageFromImage(blurredBackgroundImage)
-		content := container.New(windowLayout, canvasBackground, canvasCoverImage)
-		visualizerWindow.SetContent(content)
-	} else {
-		content := container.New(windowLayout, canvasCoverImage)
-		visualizerWindow.SetContent(content)
-	}
-	time.Sleep(sleepDuration)
+	}
 }
@@ -0,0 +1,39 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"image"
+	"math/rand"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/compose"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/palette"
+)
+
+// showLogoBackgroundGame shows the logo over one of the heroes and returns the hero shown.
+func showLogoBackgroundGame(game domain.ClientGame, gameDisplay *display, logoImage image.Image, overlayHideTime time.Time) (domain.GameArtwork, bool) {
+	heroes := game.Heroes()
+	hero := heroes[rand.Intn(len(heroes))]
+	artworkUrl := hero.Url()
+	heroImage, imgErr := imageCache.GetImage(artworkUrl)
+	if imgErr != nil {
+		warnLogger.Println("Failed to load value image for game " + game.Name + "URL: " + artworkUrl + " - " + imgErr.Error())
+		return hero, false
+	}
+	// The hero is not blurred and fills the whole window, cropped to the window's aspect ratio
+	position := mainProps.GetString("visualizer.logo.position", "bottom-left")
+	widthFactor := mainProps.GetFloat64("visualizer.logo.size", 0.35)
+	frame := newFrameImage(func(frameConfig compose.Config) image.Image {
+		return compose.LogoFrame(heroImage, logoImage, position, widthFactor, frameConfig)
+	})
+	content := container.New(layout.NewMaxLayout(),
+		frame,
+		newOverlay(game, palette.Of(hero, heroImage, paletteConfig.Size), overlayHideTime),
+		gameDisplay.launchArea)
+	gameDisplay.window.SetContent(content)
+	return hero, true
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/compose"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/filter"
+	"vg-cover-screen-saver-go/internal/app/palette"
+	"vg-cover-screen-saver-go/internal/app/selection"
+	"vg-cover-screen-saver-go/internal/app/store"
+)
+
+// runRender writes the compositions of the visualizer to PNG files without opening a window, so frames can be
+// generated on machines without display. Either one frame per game is written, named after the game, or a numbered
+// sequence of the games in slideshow order.
+func runRender(args []string) {
+	renderConfig := compose.LoadConfig(*mainProps)
+	renderFlags := flag.NewFlagSet("render", flag.ExitOnError)
+	filterExpression := renderFlags.String("filter", mainProps.GetString("visualizer.filter", "not hidden"),
+		"Only render the games matching the filter expression")
+	renderFlags.IntVar(&renderConfig.Width, "width", renderConfig.Width, "Width of the frames in pixels")
+	renderFlags.IntVar(&renderConfig.Height, "height", renderConfig.Height, "Height of the frames in pixels")
+	outputDirectory := renderFlags.String("output", mainProps.GetString("visualizer.render.directory", "frames"),
+		"Directory the frames are written to")
+	displayMode := renderFlags.String("mode", mainProps.GetString("visualizer.display.mode", coverDisplayMode),
+		"Composition of the frames, cover or logo")
+	sequence := renderFlags.Bool("sequence", false,
+		"Write a numbered frame sequence of the games in slideshow order instead of one frame per game")
+	gameCount := renderFlags.Int("games", 0,
+		"Number of games to render, all matching games when 0. Games repeat in a sequence longer than the library")
+	renderFlags.Parse(args)
+
+	renderErr := renderFrames(*filterExpression, *displayMode, *sequence, *gameCount, *outputDirectory, renderConfig)
+	if renderErr != nil {
+		fmt.Println("Rendering failed! " + renderErr.Error())
+		errorLogger.Println("Failed to render frames: " + renderErr.Error())
+		os.Exit(1)
+	}
+}
+
+func renderFrames(filterExpression string, displayMode string, sequence bool, gameCount int, outputDirectory string, renderConfig compose.Config) error {
+	games, gamesErr := loadRenderGames(filterExpression)
+	if gamesErr != nil {
+		return gamesErr
+	}
+	mkdirErr := os.MkdirAll(outputDirectory, 0755)
+	if mkdirErr != nil {
+		return mkdirErr
+	}
+
+	if !sequence {
+		if gameCount > 0 && gameCount < len(games) {
+			games = games[:gameCount]
+		}
+		for _, game := range games {
+			// The first background is used so rendering again gives the same frame
+			frame, composeErr := composeFrame(game, displayMode, 0, renderConfig)
+			if composeErr != nil {
+				warnLogger.Println(composeErr.Error())
+				continue
+			}
+			writeErr := writePng(filepath.Join(outputDirectory, getFrameName(game)+".png"), frame)
+			if writeErr != nil {
+				return writeErr
+			}
+			fmt.Println(game.Name)
+		}
+		return nil
+	}
+
+	transitions := mainProps.GetInt("visualizer.image.background.transitions", 3)
+	frameNumber := 0
+	for _, game := range pickSequenceGames(games, gameCount) {
+		// Like the slideshow each game is shown with several of its backgrounds
+		for i := 0; i <= transitions; i++ {
+			frame, composeErr := composeFrame(game, displayMode, -1, renderConfig)
+			if composeErr != nil {
+				warnLogger.Println(composeErr.Error())
+				break
+			}
+			frameNumber++
+			writeErr := writePng(filepath.Join(outputDirectory, fmt.Sprintf("frame-%05d.png", frameNumber)), frame)
+			if writeErr != nil {
+				return writeErr
+			}
+		}
+		fmt.Println(game.Name)
+	}
+	return nil
+}
+
+// pickSequenceGames picks the number of games in slideshow order, all games when the number is 0.
+func pickSequenceGames(games []domain.ClientGame, gameCount int) []domain.ClientGame {
+	if gameCount <= 0 {
+		gameCount = len(games)
+	}
+	// The persisted slideshow state is left alone, a render is not a viewing
+	sequenceSelection := selection.New(
+		mainProps.GetString("visualizer.selection.strategy", "random"),
+		mainProps.GetInt("visualizer.selection.recency.size", 20),
+		nil)
+	sequenceGames := make([]domain.ClientGame, 0, gameCount)
+	for len(sequenceGames) < gameCount {
+		sequenceGames = append(sequenceGames, games[sequenceSelection.Next(games, nil)])
+	}
+	return sequenceGames
+}
+
+// loadRenderGames returns the stored games matching the filter with their local artworks applied. Games are not synced,
+// rendering only uses what the visualizer fetched before.
+func loadRenderGames(filterExpression string) ([]domain.ClientGame, error) {
+	gameFilter, filterErr := filter.Parse(filterExpression)
+	if filterErr != nil {
+		return nil, errors.New("Invalid filter: " + filterErr.Error())
+	}
+	gameStore, loadDbErr := store.Open(dbFile)
+	if loadDbErr != nil {
+		return nil, loadDbErr
+	}
+	defer gameStore.Close()
+	ownedGames, getGamesErr := gameStore.GetGames()
+	if getGamesErr != nil {
+		return nil, getGamesErr
+	}
+	loadLocalArtworks()
+
+	var games []domain.ClientGame
+	for _, game := range filter.Apply(ownedGames, gameFilter) {
+		if localArtworks != nil {
+			game = localArtworks.Apply(game)
+		}
+		games = append(games, game)
+	}
+	if len(games) == 0 {
+		return nil, errors.New("No games match the library filter")
+	}
+	return games, nil
+}
+
+// composeFrame composes the game the way the display mode shows it, with the background or hero at the index or a
+// random one when the index is negative. Logo mode falls back to the cover composition like the visualizer does.
+func composeFrame(game domain.ClientGame, displayMode string, backgroundIndex int, renderConfig compose.Config) (image.Image, error) {
+	if displayMode == logoDisplayMode {
+		logo, logoFound := game.Logo()
+		heroes := game.Heroes()
+		if logoFound && len(heroes) > 0 {
+			logoImage, logoErr := imageCache.GetImage(logo.Url())
+			heroImage, heroErr := imageCache.GetImage(pickArtwork(heroes, backgroundIndex).Url())
+			if logoErr == nil && heroErr == nil {
+				return compose.LogoFrame(heroImage, logoImage,
+					mainProps.GetString("visualizer.logo.position", "bottom-left"),
+					mainProps.GetFloat64("visualizer.logo.size", 0.35),
+					renderConfig), nil
+			}
+		}
+	}
+
+	cover, coverFound := game.Cover()
+	if !coverFound {
+		return nil, errors.New("No cover for game " + game.Name)
+	}
+	coverImage, coverErr := effectsCache.GetImage(cover.Url(), effectsConfig.Cover)
+	if coverErr != nil {
+		return nil, errors.New("Failed to load value image for game " + game.Name + "URL: " + cover.Url() + " - " + coverErr.Error())
+	}
+	var backgroundImage image.Image
+	if backgrounds := game.Backgrounds(); len(backgrounds) > 0 {
+		background := pickArtwork(backgrounds, backgroundIndex)
+		var backgroundErr error
+		backgroundImage, backgroundErr = effectsCache.GetImage(background.Url(), effectsConfig.Background)
+		if backgroundErr != nil {
+			return nil, errors.New("Failed to load value image for game " + game.Name + "URL: " + background.Url() + " - " + backgroundErr.Error())
+		}
+	} else {
+		// Like in the visualizer, covers without background are shown over their own colours
+		backgroundImage = palette.Background(palette.Of(cover, coverImage, paletteConfig.Size), paletteConfig.Background, 256, 256)
+	}
+	return compose.CoverFrame(coverImage, backgroundImage, renderConfig), nil
+}
+
+func pickArtwork(artworks []domain.GameArtwork, index int) domain.GameArtwork {
+	if index < 0 || index >= len(artworks) {
+		return artworks[rand.Intn(len(artworks))]
+	}
+	return artworks[index]
+}
+
+// getFrameName names the frame of a game after its key, which stays the same when the game is renamed.
+func getFrameName(game domain.ClientGame) string {
+	return strings.ToLower(game.Source.String()) + "-" + game.SourceId
+}
+
+func writePng(path string, frame image.Image) error {
+	frameFile, createErr := os.Create(path)
+	if createErr != nil {
+		return createErr
+	}
+	encodeErr := png.Encode(frameFile, frame)
+	closeErr := frameFile.Close()
+	if encodeErr != nil {
+		return encodeErr
+	}
+	return closeErr
+}
@@ -0,0 +1,163 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/screensaver"
+	"vg-cover-screen-saver-go/internal/app/slideshow"
+)
+
+// screensaverGracePeriod is the time after the screensaver opened in which input is ignored, the key that started it
+// may still be coming in.
+const screensaverGracePeriod = time.Second
+
+// screensaverMotionThreshold is the distance in pixels the mouse must move to wake the screensaver.
+const screensaverMotionThreshold = 10
+
+// screensaverConfig is set when the visualizer runs as screensaver, nil when it runs in a window.
+var screensaverConfig *screensaver.Config
+
+var screensaverState struct {
+	mutex   sync.Mutex
+	started time.Time
+	woken   bool
+	motion  *screensaver.MotionFilter
+}
+
+// runScreensaver shows the visualizer fullscreen without cursor until keyboard or mouse are used. With an idle delay it
+// waits in the background and starts the screensaver each time the user has been idle that long.
+func runScreensaver(args []string) {
+	config := screensaver.LoadConfig(*mainProps)
+	screensaverFlags := flag.NewFlagSet("screensaver", flag.ExitOnError)
+	filterExpression := screensaverFlags.String("filter", mainProps.GetString("visualizer.filter", "not hidden"),
+		"Only show the games matching the filter expression")
+	screensaverFlags.StringVar(&config.OnInput, "on-input", config.OnInput,
+		"What keyboard or mouse input does, exit the screensaver or pause the slideshow")
+	screensaverFlags.DurationVar(&config.IdleDelay, "idle", config.IdleDelay,
+		"Start the screensaver each time the user is idle for this long, e.g. 10m. Starts it right away when 0")
+	screensaverFlags.Parse(args)
+
+	if config.OnInput != screensaver.ExitOnInput && config.OnInput != screensaver.PauseOnInput {
+		fmt.Println("Invalid input action " + config.OnInput + ", must be exit or pause")
+		os.Exit(1)
+	}
+	if config.IdleDelay > 0 {
+		idleErr := runIdleScreensaver(*filterExpression, config)
+		fmt.Println("Waiting for idle failed! " + idleErr.Error())
+		errorLogger.Println("Failed to wait for idle: " + idleErr.Error())
+		os.Exit(1)
+	}
+	screensaverConfig = &config
+	runVisualizer(*filterExpression)
+}
+
+// runIdleScreensaver starts the screensaver whenever the user is idle for the delay. The screensaver runs as its own
+// process, a Fyne app cannot run again once it quit, and always exits on input as nothing would close it otherwise.
+func runIdleScreensaver(filterExpression string, config screensaver.Config) error {
+	executable, executableErr := os.Executable()
+	if executableErr != nil {
+		return executableErr
+	}
+	trigger := screensaver.NewTrigger(screensaver.CommandIdleProvider{Command: config.IdleCommand}, config.IdleDelay)
+	for {
+		waitErr := trigger.Wait(config.PollInterval)
+		if waitErr != nil {
+			return waitErr
+		}
+		infoLogger.Println("Idle for " + config.IdleDelay.String() + ", starting the screensaver")
+		screensaverCommand := exec.Command(executable, "screensaver",
+			"-idle", "0", "-on-input", screensaver.ExitOnInput, "-filter", filterExpression)
+		screensaverCommand.Stdout = os.Stdout
+		screensaverCommand.Stderr = os.Stderr
+		runErr := screensaverCommand.Run()
+		var exitErr *exec.ExitError
+		if runErr != nil && !errors.As(runErr, &exitErr) {
+			return runErr
+		}
+		if runErr != nil {
+			warnLogger.Println("Screensaver exited with " + runErr.Error())
+		}
+	}
+}
+
+// startScreensaver makes the window cover its monitor and lets any key close the screensaver, modifier keys included.
+// Windows of display areas, configured or one per connected monitor, already cover their areas.
+func startScreensaver(screensaverDisplays []*display) {
+	screensaverState.mutex.Lock()
+	screensaverState.started = time.Now()
+	screensaverState.motion = screensaver.NewMotionFilter(screensaverMotionThreshold)
+	screensaverState.mutex.Unlock()
+	for _, screensaverDisplay := range screensaverDisplays {
+		if !screensaverDisplay.placed {
+			screensaverDisplay.window.SetFullScreen(true)
+		}
+		if desktopCanvas, isDesktop := screensaverDisplay.window.Canvas().(desktop.Canvas); isDesktop {
+			desktopCanvas.SetOnKeyDown(func(_ *fyne.KeyEvent) {
+				if screensaverConfig.OnInput == screensaver.ExitOnInput {
+					wakeScreensaver()
+				}
+			})
+		}
+	}
+}
+
+// wakeScreensaver exits the screensaver or pauses the slideshow on the first input. It returns true when the input is
+// used up by waking the screensaver and is not to be handled as usual.
+func wakeScreensaver() bool {
+	if screensaverConfig == nil {
+		return false
+	}
+	screensaverState.mutex.Lock()
+	if time.Since(screensaverState.started) < screensaverGracePeriod {
+		screensaverState.mutex.Unlock()
+		return true
+	}
+	if screensaverState.woken {
+		screensaverState.mutex.Unlock()
+		// An exiting screensaver must not launch games on its way out
+		return screensaverConfig.OnInput == screensaver.ExitOnInput
+	}
+	screensaverState.woken = true
+	screensaverState.mutex.Unlock()
+
+	if screensaverConfig.OnInput == screensaver.PauseOnInput {
+		sendToDisplays(slideshow.Command{Kind: slideshow.Pause})
+	} else {
+		fyne.CurrentApp().Quit()
+	}
+	return true
+}
+
+// screensaverMouseMoved wakes the screensaver when the mouse really moved.
+func screensaverMouseMoved(position fyne.Position) {
+	if screensaverConfig == nil {
+		return
+	}
+	screensaverState.mutex.Lock()
+	moved := screensaverState.motion.Moved(position.X, position.Y)
+	screensaverState.mutex.Unlock()
+	if moved {
+		wakeScreensaver()
+	}
+}
+
+// screensaverCursorHidden is true while the screensaver runs, a paused screensaver shows the cursor again so games can
+// be launched.
+func screensaverCursorHidden() bool {
+	if screensaverConfig == nil {
+		return false
+	}
+	screensaverState.mutex.Lock()
+	defer screensaverState.mutex.Unlock()
+	return !screensaverState.woken
+}
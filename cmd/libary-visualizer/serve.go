@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"vg-cover-screen-saver-go/internal/app/artwork"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/filter"
+	"vg-cover-screen-saver-go/internal/app/igdb"
+	"vg-cover-screen-saver-go/internal/app/selection"
+	"vg-cover-screen-saver-go/internal/app/server"
+	"vg-cover-screen-saver-go/internal/app/store"
+)
+
+// runServe serves the library and a browser slideshow over HTTP instead of showing the slideshow in a window, for
+// displays that only have a browser.
+func runServe(args []string) {
+	serverConfig := server.LoadConfig(*mainProps, *secretProps)
+	serveFlags := flag.NewFlagSet("serve", flag.ExitOnError)
+	filterExpression := serveFlags.String("filter", mainProps.GetString("visualizer.filter", "not hidden"),
+		"Only serve the games matching the filter expression")
+	serveFlags.StringVar(&serverConfig.Address, "address", serverConfig.Address, "Address to listen on, e.g. :8080")
+	serveFlags.BoolVar(&serverConfig.Sync, "sync", serverConfig.Sync,
+		"Show the same game in all browsers by default, otherwise each browser picks its own games")
+	serveFlags.Parse(args)
+
+	serveErr := serve(*filterExpression, serverConfig)
+	if serveErr != nil {
+		fmt.Println("Serving failed! " + serveErr.Error())
+		errorLogger.Println("Failed to serve: " + serveErr.Error())
+		os.Exit(1)
+	}
+}
+
+func serve(filterExpression string, serverConfig server.Config) error {
+	serveFilter, filterErr := filter.Parse(filterExpression)
+	if filterErr != nil {
+		return filterErr
+	}
+	gameStore, loadDbErr := store.Open(dbFile)
+	if loadDbErr != nil {
+		return loadDbErr
+	}
+	defer gameStore.Close()
+	syncErr := syncGames(gameStore)
+	if syncErr != nil {
+		errorLogger.Println("Failed to sync games: " + syncErr.Error())
+	}
+	if loadLocalArtworks() {
+		localArtworkWatcher, watchErr := localArtworks.Watch()
+		if watchErr != nil {
+			warnLogger.Println("Failed to watch local artworks: " + watchErr.Error())
+		} else {
+			defer localArtworkWatcher.Close()
+		}
+	}
+	// The browser slideshow keeps a selection state of its own, it would take the games of the visualizer's otherwise
+	serveSelection := selection.New(
+		mainProps.GetString("visualizer.selection.strategy", "random"),
+		mainProps.GetInt("visualizer.selection.recency.size", 20),
+		selection.WithPrefix(gameStore, "serve:"))
+
+	fmt.Println("Serving the library on " + serverConfig.Address)
+	return server.New(serverConfig, gameStore, serveFilter, serveSelection, localArtworks, imageCache, librarySyncer{gameStore: gameStore}).ListenAndServe()
+}
+
+// librarySyncer lets the server's API sync the library like the visualizer does at start.
+type librarySyncer struct {
+	gameStore *store.Store
+}
+
+func (syncer librarySyncer) SyncGames() error {
+	return syncGames(syncer.gameStore)
+}
+
+func (syncer librarySyncer) MatchIgdbGame(game domain.ClientGame, igdbId int) (domain.ClientGame, error) {
+	igdbGameData, errorIgdb := igdb.GetGameById(game, igdbId, *secretProps)
+	if errorIgdb != nil {
+		return game, errorIgdb
+	}
+	providerPriority := artwork.ParsePriority(mainProps.GetString("visualizer.artwork.providers", "igdb,steamgriddb,steam"))
+	igdbGameData.Artworks = getArtworks(igdbGameData, getArtworkProviders(), providerPriority)
+	return igdbGameData, nil
+}
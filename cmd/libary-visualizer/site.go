@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"vg-cover-screen-saver-go/internal/app/site"
+)
+
+// runSite generates a static website of the library from the stored games and the image cache.
+func runSite(args []string) {
+	siteConfig := site.LoadConfig(*mainProps)
+	siteFlags := flag.NewFlagSet("site", flag.ExitOnError)
+	filterExpression := siteFlags.String("filter", mainProps.GetString("visualizer.filter", "not hidden"),
+		"Only publish the games matching the filter expression")
+	output := siteFlags.String("output", mainProps.GetString("visualizer.site.directory", "site"),
+		"Directory the site is written to")
+	siteFlags.StringVar(&siteConfig.Title, "title", siteConfig.Title, "Title of the site")
+	siteFlags.IntVar(&siteConfig.ThumbnailWidth, "thumbnail-width", siteConfig.ThumbnailWidth,
+		"Width of the cover thumbnails in the index, 0 uses the full covers")
+	siteFlags.Parse(args)
+
+	games, gamesErr := loadRenderGames(*filterExpression)
+	if gamesErr == nil {
+		gamesErr = site.Generate(games, *output, imageCache, siteConfig)
+	}
+	if gamesErr != nil {
+		fmt.Println("Generating the site failed! " + gamesErr.Error())
+		errorLogger.Println("Failed to generate site: " + gamesErr.Error())
+		os.Exit(1)
+	}
+	fmt.Println("Site written to " + *output)
+}
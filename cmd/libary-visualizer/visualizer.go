@@ -0,0 +1,446 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"image"
+	"image/color"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/compose"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/filter"
+	"vg-cover-screen-saver-go/internal/app/mqtt"
+	"vg-cover-screen-saver-go/internal/app/overlay"
+	"vg-cover-screen-saver-go/internal/app/palette"
+	"vg-cover-screen-saver-go/internal/app/remote"
+	"vg-cover-screen-saver-go/internal/app/selection"
+	"vg-cover-screen-saver-go/internal/app/slideshow"
+	"vg-cover-screen-saver-go/internal/app/store"
+)
+
+// The desktop visualizer shows the slideshow in Fyne windows. Builds with the headless tag leave it out, together with
+// Fyne and the X11 libraries it needs, for the commands writing frames, sites and wallpapers.
+
+var (
+	currentGameMutex sync.Mutex
+	overlayConfig    overlay.Config
+	gameFilter       filter.Filter
+	// slideshowController steers the slideshow of the first display from the keyboard and the remote control
+	slideshowController = slideshow.New(100)
+)
+
+const (
+	visualizerTitle = "Game Library Visualizer"
+	// retryDelay is the time the slideshow waits before trying again when it has no game to show
+	retryDelay = 5 * time.Second
+)
+
+func runVisualizer(filterExpression string) {
+	var filterErr error
+	gameFilter, filterErr = filter.Parse(filterExpression)
+	if filterErr != nil {
+		fmt.Println("Invalid filter: " + filterErr.Error())
+		errorLogger.Println("Invalid filter " + filterExpression + ": " + filterErr.Error())
+		return
+	}
+	slideshowController.SetFilter(filterExpression)
+	overlayConfig = overlay.LoadConfig(*mainProps)
+
+	visualizer := app.New()
+	gameStore, loadDbErr := store.Open(dbFile)
+	if loadDbErr != nil {
+		errorLogger.Println("Failed to load DB: " + loadDbErr.Error())
+		return
+	}
+	var displaysErr error
+	displays, displaysErr = newDisplays(visualizer, gameStore)
+	if displaysErr != nil {
+		errorLogger.Println("Failed to open displays: " + displaysErr.Error())
+		return
+	}
+	if screensaverConfig != nil {
+		startScreensaver(displays)
+	}
+	// TODO loading screen
+	// TODO thread loading of images incrementally in background while displaying already and newly added images
+	syncErr := syncGames(gameStore)
+	if syncErr != nil {
+		errorLogger.Println("Failed to sync games: " + syncErr.Error())
+	}
+
+	ownedGames, getGamesErr := gameStore.GetGames()
+	if getGamesErr != nil {
+		errorLogger.Println("Failed to fetch owned games: " + getGamesErr.Error())
+		return
+	}
+	if len(ownedGames) == 0 {
+		errorLogger.Println("No games to show")
+		return
+	}
+	if loadLocalArtworks() {
+		localArtworkWatcher, watchErr := localArtworks.Watch()
+		if watchErr != nil {
+			warnLogger.Println("Failed to watch local artworks: " + watchErr.Error())
+		} else {
+			defer localArtworkWatcher.Close()
+		}
+	}
+	_, mainPropsError := strconv.Atoi(mainProps.MustGet("visualizer.image.time.seconds"))
+	if mainPropsError != nil {
+		errorLogger.Println("Failed to load value for visualizer.image.time.seconds. Must me numeric : " + mainPropsError.Error())
+		return
+	}
+
+	for _, gameDisplay := range displays {
+		handleDisplayInput(visualizer, gameStore, gameDisplay)
+	}
+
+	remoteConfig := remote.LoadConfig(*mainProps, *secretProps)
+	if remoteConfig.Enabled {
+		go func() {
+			remoteErr := remote.New(remoteConfig, slideshowController).ListenAndServe()
+			errorLogger.Println("Remote control stopped: " + remoteErr.Error())
+		}()
+	}
+	mqttConfig := mqtt.LoadConfig(*mainProps, *secretProps)
+	if mqttConfig.Enabled {
+		mqttPublisher := mqtt.New(mqttConfig, slideshowController)
+		mqttErr := mqttPublisher.Start()
+		if mqttErr != nil {
+			errorLogger.Println("Failed to connect to MQTT broker: " + mqttErr.Error())
+		} else {
+			defer mqttPublisher.Close()
+		}
+	}
+	for _, gameDisplay := range displays {
+		if mainProps.GetString("visualizer.display.mode", coverDisplayMode) == wallDisplayMode {
+			runWall(ownedGames, gameDisplay)
+		} else {
+			go runSlideshow(ownedGames, gameDisplay)
+		}
+	}
+
+	for displayIndex, gameDisplay := range displays {
+		if gameDisplay.placed {
+			go placeWindow(gameDisplay)
+		}
+		if displayIndex > 0 {
+			gameDisplay.window.Show()
+		}
+	}
+	displays[0].window.ShowAndRun()
+	defer func(gameStore *store.Store) {
+		dbCloseErr := gameStore.Close()
+		if dbCloseErr != nil {
+			errorLogger.Println("Failed to close DB properly: " + dbCloseErr.Error())
+		}
+	}(gameStore)
+}
+
+// handleDisplayInput lets the keys and the launch area of the display act on the game it shows and steer its
+// slideshow.
+func handleDisplayInput(visualizer fyne.App, gameStore *store.Store, gameDisplay *display) {
+	withCurrentGame := func(action func(gameStore *store.Store, game domain.ClientGame)) func() {
+		return func() {
+			currentGameMutex.Lock()
+			game := gameDisplay.currentGame
+			currentGameMutex.Unlock()
+			if game != nil {
+				action(gameStore, *game)
+			}
+		}
+	}
+	gameDisplay.window.Canvas().SetOnTypedKey(func(keyEvent *fyne.KeyEvent) {
+		if wakeScreensaver() {
+			return
+		}
+		switch keyEvent.Name {
+		case fyne.KeyReturn, fyne.KeyEnter:
+			withCurrentGame(launchCurrentGame)()
+		case fyne.KeyF:
+			withCurrentGame(toggleFavourite)()
+		case fyne.KeyH:
+			withCurrentGame(toggleHidden)()
+		case fyne.KeyRight:
+			gameDisplay.controller.Send(slideshow.Command{Kind: slideshow.Next})
+		case fyne.KeyLeft:
+			gameDisplay.controller.Send(slideshow.Command{Kind: slideshow.Previous})
+		case fyne.KeySpace:
+			// The other displays follow the pause of the first
+			slideshowController.Send(slideshow.Command{Kind: slideshow.TogglePause})
+		case fyne.KeyEscape:
+			if screensaverConfig != nil {
+				visualizer.Quit()
+			}
+		}
+	})
+	gameDisplay.launchArea = newLaunchArea(withCurrentGame(launchCurrentGame))
+}
+
+// pickGame picks the index of the next game to show with the selection among the games matching the library filter and
+// not excluded by key. The game comes with its local artworks applied.
+func pickGame(games []domain.ClientGame, gameSelection selection.Strategy, excludedKeys map[string]bool) (int, domain.ClientGame, bool) {
+	// The filter is applied on every pick so a game hidden while the slideshow runs is skipped right away
+	var visibleIndexes []int
+	var visibleGames []domain.ClientGame
+	currentGameMutex.Lock()
+	for gameIndex, game := range games {
+		if gameFilter.Match(game) {
+			visibleIndexes = append(visibleIndexes, gameIndex)
+			visibleGames = append(visibleGames, game)
+		}
+	}
+	currentGameMutex.Unlock()
+
+	// The excluded games are left to the selection so a shuffle bag keeps them for later
+	visibleIndex := gameSelection.Next(visibleGames, excludedKeys)
+	if visibleIndex < 0 {
+		return 0, domain.ClientGame{}, false
+	}
+	// The picked game is the copy taken under the lock, the displays update the games list while showing them
+	gameIndex := visibleIndexes[visibleIndex]
+	game := visibleGames[visibleIndex]
+	if localArtworks != nil {
+		game = localArtworks.Apply(game)
+	}
+	return gameIndex, game, true
+}
+
+// runSlideshow shows one game after the other on the display until the visualizer is closed, steered by the commands
+// of the display's controller.
+func runSlideshow(games []domain.ClientGame, gameDisplay *display) {
+	requestedKey := ""
+	for {
+		command, interrupted := showGame(games, gameDisplay, requestedKey)
+		requestedKey = ""
+		if !interrupted {
+			continue
+		}
+		switch command.Kind {
+		case slideshow.Previous:
+			requestedKey, _ = gameDisplay.controller.PreviousGame()
+		case slideshow.ShowGame:
+			requestedKey = command.GameKey
+		case slideshow.SetFilter:
+			setGameFilter(command.Filter)
+		}
+	}
+}
+
+// setGameFilter replaces the library filter while the slideshow runs. Invalid filters are ignored, the slideshow keeps
+// the filter it has.
+func setGameFilter(filterExpression string) {
+	newFilter, filterErr := filter.Parse(filterExpression)
+	if filterErr != nil {
+		warnLogger.Println("Invalid filter " + filterExpression + ": " + filterErr.Error())
+		return
+	}
+	currentGameMutex.Lock()
+	gameFilter = newFilter
+	currentGameMutex.Unlock()
+	slideshowController.SetFilter(filterExpression)
+	infoLogger.Println("Library filter set to " + filterExpression)
+}
+
+// showGame shows the game of the key, or the next picked game when the key is empty, unknown or shown by another
+// display, with several of its backgrounds. It returns early with the command interrupting it.
+func showGame(games []domain.ClientGame, gameDisplay *display, requestedKey string) (slideshow.Command, bool) {
+	gameIndex, game, gameFound := findGame(games, requestedKey)
+	if !gameFound || !setPickedGame(gameDisplay, game) {
+		gameIndex, game, gameFound = pickDisplayGame(games, gameDisplay)
+	}
+	if !gameFound {
+		warnLogger.Println("No games match the library filter")
+		return gameDisplay.controller.Wait(retryDelay)
+	}
+	backgroundTransitionsNumber, mainPropsError := strconv.Atoi(mainProps.MustGet("visualizer.image.background.transitions"))
+	if mainPropsError != nil {
+		errorLogger.Println("Failed to load value for visualizer.image.background.transitions. Must me numeric : " + mainPropsError.Error())
+		return gameDisplay.controller.Wait(retryDelay)
+	}
+	imageCoverTime, mainPropsError := strconv.Atoi(mainProps.MustGet("visualizer.image.time.seconds"))
+	if mainPropsError != nil {
+		errorLogger.Println("Failed to load value for visualizer.image.time.seconds. Must me numeric : " + mainPropsError.Error())
+		return gameDisplay.controller.Wait(retryDelay)
+	}
+	sleepDuration := time.Millisecond * time.Duration(1000*(imageCoverTime/backgroundTransitionsNumber))
+	var overlayHideTime time.Time
+	if overlayConfig.HideAfter > 0 {
+		overlayHideTime = time.Now().Add(overlayConfig.HideAfter)
+	}
+
+	// Logo mode falls back to the cover layout for games without logo or hero
+	if mainProps.GetString("visualizer.display.mode", coverDisplayMode) == logoDisplayMode {
+		if logo, logoFound := game.Logo(); logoFound && len(game.Heroes()) > 0 {
+			logoImage, imgErr := imageCache.GetImage(logo.Url())
+			if imgErr == nil {
+				setCurrentGame(&games[gameIndex], gameDisplay)
+				for i := 0; i <= backgroundTransitionsNumber; i++ {
+					hero, shown := showLogoBackgroundGame(game, gameDisplay, logoImage, overlayHideTime)
+					if !shown {
+						continue
+					}
+					gameDisplay.controller.Shown(slideshow.NewEvent(game, hero))
+					if command, interrupted := gameDisplay.controller.Wait(sleepDuration); interrupted {
+						return command, true
+					}
+				}
+				return slideshow.Command{}, false
+			}
+			warnLogger.Println("Failed to load value image for game " + game.Name + "URL: " + logo.Url() + " - " + imgErr.Error())
+		}
+	}
+
+	cover, coverFound := game.Cover()
+	if !coverFound {
+		return gameDisplay.controller.Wait(retryDelay)
+	}
+	setCurrentGame(&games[gameIndex], gameDisplay)
+	// TODO Error handling
+	artworkUrl := cover.Url()
+	coverImage, imgErr := effectsCache.GetImage(artworkUrl, effectsConfig.Cover)
+	if imgErr != nil {
+		warnLogger.Println("Failed to load value image for game " + game.Name + "URL: " + artworkUrl + " - " + imgErr.Error())
+		return gameDisplay.controller.Wait(retryDelay)
+	}
+	for i := 0; i <= backgroundTransitionsNumber; i++ {
+		background, shown := showBackgroundGame(game, gameDisplay, coverImage, overlayHideTime)
+		if !shown {
+			continue
+		}
+		gameDisplay.controller.Shown(slideshow.NewEvent(game, background))
+		if command, interrupted := gameDisplay.controller.Wait(sleepDuration); interrupted {
+			return command, true
+		}
+	}
+	return slideshow.Command{}, false
+}
+
+// findGame returns the game of the key with its local artworks applied, false when the key is empty or unknown. Games
+// asked for by key are shown even when the library filter excludes them.
+func findGame(games []domain.ClientGame, key string) (int, domain.ClientGame, bool) {
+	if key == "" {
+		return 0, domain.ClientGame{}, false
+	}
+	currentGameMutex.Lock()
+	defer currentGameMutex.Unlock()
+	for gameIndex, game := range games {
+		if game.Key() == key {
+			if localArtworks != nil {
+				game = localArtworks.Apply(game)
+			}
+			return gameIndex, game, true
+		}
+	}
+	return 0, domain.ClientGame{}, false
+}
+
+// showBackgroundGame shows the cover over one of the backgrounds and returns the background shown, the cover when the
+// game has no background. Spanning displays show the cover over a hero instead.
+func showBackgroundGame(game domain.ClientGame, gameDisplay *display, coverImage image.Image, overlayHideTime time.Time) (domain.GameArtwork, bool) {
+	var content fyne.CanvasObject
+	var background domain.GameArtwork
+	var contentErr error
+	if gameDisplay.spanning {
+		content, background, contentErr = newSpanContent(game, gameDisplay, coverImage, overlayHideTime)
+	} else {
+		content, background, contentErr = newBackgroundContent(game, coverImage, overlayHideTime)
+	}
+	if contentErr != nil {
+		warnLogger.Println(contentErr.Error())
+		return domain.GameArtwork{}, false
+	}
+	gameDisplay.window.SetContent(container.NewMax(content, gameDisplay.launchArea))
+	return background, true
+}
+
+// newBackgroundContent lays the overlay over the cover composed in front of a random background of the game, the way
+// the render command composes it. Games without background are shown over the colours of their cover. It returns the
+// background used, the cover when there is none.
+func newBackgroundContent(game domain.ClientGame, coverImage image.Image, overlayHideTime time.Time) (fyne.CanvasObject, domain.GameArtwork, error) {
+	windowLayout := layout.NewMaxLayout()
+	backgrounds := game.Backgrounds()
+	if len(backgrounds) == 0 {
+		cover, _ := game.Cover()
+		coverPalette := palette.Of(cover, coverImage, paletteConfig.Size)
+		// The small gradient is stretched smoothly over the window
+		paletteBackground := palette.Background(coverPalette, paletteConfig.Background, 256, 256)
+		frame := newFrameImage(func(frameConfig compose.Config) image.Image {
+			return compose.CoverFrame(coverImage, paletteBackground, frameConfig)
+		})
+		return container.New(windowLayout, frame, newOverlay(game, coverPalette, overlayHideTime)), cover, nil
+	}
+	background := backgrounds[rand.Intn(len(backgrounds))]
+	artworkUrl := background.Url()
+	backgroundImage, imgErr := effectsCache.GetImage(artworkUrl, effectsConfig.Background)
+	if imgErr != nil {
+		return nil, background, errors.New("Failed to load value image for game " + game.Name + "URL: " + artworkUrl + " - " + imgErr.Error())
+	}
+	frame := newFrameImage(func(frameConfig compose.Config) image.Image {
+		return compose.CoverFrame(coverImage, backgroundImage, frameConfig)
+	})
+	return container.New(windowLayout, frame, newOverlay(game, palette.Of(background, backgroundImage, paletteConfig.Size), overlayHideTime)), background, nil
+}
+
+// frameLayout fills the window with the frame composed at the pixel size of the window, composed again whenever the
+// window is resized, so the window shows what the render command writes.
+type frameLayout struct {
+	composeFrame func(frameConfig compose.Config) image.Image
+	size         fyne.Size
+}
+
+// newFrameImage returns the frame of the compose function filling its container.
+func newFrameImage(composeFrame func(frameConfig compose.Config) image.Image) fyne.CanvasObject {
+	frameImage := canvas.NewImageFromImage(nil)
+	frameImage.FillMode = canvas.ImageFillStretch
+	return container.New(&frameLayout{composeFrame: composeFrame}, frameImage)
+}
+
+func (frame *frameLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	for _, object := range objects {
+		if frameImage, isImage := object.(*canvas.Image); isImage && size != frame.size && size.Width >= 1 && size.Height >= 1 {
+			scale := float32(1)
+			if objectCanvas := fyne.CurrentApp().Driver().CanvasForObject(object); objectCanvas != nil {
+				scale = objectCanvas.Scale()
+			}
+			frameImage.Image = frame.composeFrame(compose.Config{Width: int(size.Width * scale), Height: int(size.Height * scale)})
+			frameImage.Refresh()
+		}
+		object.Resize(size)
+		object.Move(fyne.NewPos(0, 0))
+	}
+	frame.size = size
+}
+
+func (frame *frameLayout) MinSize(_ []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+// newOverlay creates the metadata panel for the game in the colours of the palette of the background behind it, hidden
+// once the configured overlay time has passed.
+func newOverlay(game domain.ClientGame, backgroundPalette []color.NRGBA, hideTime time.Time) fyne.CanvasObject {
+	if !overlayConfig.Enabled {
+		return layout.NewSpacer()
+	}
+	panel := overlay.NewPanel(overlay.GetLines(game, overlayConfig), backgroundPalette, overlayConfig)
+	if !hideTime.IsZero() {
+		remainingTime := time.Until(hideTime)
+		if remainingTime <= 0 {
+			panel.Hide()
+		} else {
+			time.AfterFunc(remainingTime, panel.Hide)
+		}
+	}
+	return panel
+}
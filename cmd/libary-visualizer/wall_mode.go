@@ -0,0 +1,394 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"image"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/palette"
+	"vg-cover-screen-saver-go/internal/app/slideshow"
+)
+
+const wallDisplayMode = "wall"
+
+// colourWallOrder sorts the covers of the wall by colour, visualizer.wall.order is random otherwise.
+const colourWallOrder = "colour"
+
+// coverAspectRatio is the width to height ratio of the tiles, the one of IGDB and Steam library covers.
+const coverAspectRatio = float32(3) / 4
+
+// wallLayout lays the tiles out in a grid of columns and rows, the tiles as big as the window allows while keeping the
+// cover aspect ratio. The grid is centered in the window.
+type wallLayout struct {
+	columns int
+	rows    int
+}
+
+func (wall *wallLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	tileSize := fyne.NewSize(size.Width/float32(wall.columns), size.Width/float32(wall.columns)/coverAspectRatio)
+	if tileSize.Height*float32(wall.rows) > size.Height {
+		tileSize = fyne.NewSize(size.Height/float32(wall.rows)*coverAspectRatio, size.Height/float32(wall.rows))
+	}
+	offset := fyne.NewPos((size.Width-tileSize.Width*float32(wall.columns))/2, (size.Height-tileSize.Height*float32(wall.rows))/2)
+	for tileIndex, object := range objects {
+		object.Resize(tileSize)
+		object.Move(fyne.NewPos(
+			offset.X+tileSize.Width*float32(tileIndex%wall.columns),
+			offset.Y+tileSize.Height*float32(tileIndex/wall.columns)))
+	}
+}
+
+func (wall *wallLayout) MinSize(_ []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+// flipLayout shrinks the cover of a tile horizontally around its center, a scale going from 1 to 0 and back looks
+// like the tile turning over.
+type flipLayout struct {
+	scale float32
+}
+
+func (flip *flipLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	for _, object := range objects {
+		object.Resize(fyne.NewSize(size.Width*flip.scale, size.Height))
+		object.Move(fyne.NewPos(size.Width*(1-flip.scale)/2, 0))
+	}
+}
+
+func (flip *flipLayout) MinSize(_ []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+type wallTile struct {
+	position   int
+	gameIndex  int
+	gameKey    string
+	image      *canvas.Image
+	coverImage image.Image
+	flip       *flipLayout
+	cover      *fyne.Container
+	container  *fyne.Container
+}
+
+func newWallTile(position int) *wallTile {
+	tileImage := canvas.NewImageFromImage(nil)
+	tileImage.FillMode = canvas.ImageFillContain
+	flip := &flipLayout{scale: 1}
+	cover := container.New(flip, tileImage)
+	return &wallTile{
+		position:  position,
+		gameIndex: -1,
+		image:     tileImage,
+		flip:      flip,
+		cover:     cover,
+		container: container.NewMax(cover),
+	}
+}
+
+// show turns the tile over to the new cover.
+func (tile *wallTile) show(coverImage image.Image) {
+	setScale := func(scale float32) {
+		tile.flip.scale = scale
+		tile.flip.Layout(tile.cover.Objects, tile.cover.Size())
+		canvas.Refresh(tile.image)
+	}
+	turnOut := fyne.NewAnimation(300*time.Millisecond, func(progress float32) {
+		setScale(1 - progress)
+	})
+	turnIn := fyne.NewAnimation(300*time.Millisecond, func(progress float32) {
+		setScale(progress)
+	})
+	time.AfterFunc(turnOut.Duration, func() {
+		tile.image.Image = coverImage
+		turnIn.Start()
+	})
+	turnOut.Start()
+}
+
+// wall shows many covers at once. Every flip interval the tile shown the longest turns over to another game, and with
+// featuring enabled one of the tiles is shown over its blurred background like the cover mode does every feature
+// interval. Sorted by colour the covers run through the colour wheel from the top left to the bottom right tile.
+type wall struct {
+	games            []domain.ClientGame
+	display          *display
+	colourSorted     bool
+	tiles            []*wallTile
+	mutex            sync.Mutex
+	flipOrder        []int
+	featured         *fyne.Container
+	featuredDuration time.Duration
+}
+
+func runWall(games []domain.ClientGame, gameDisplay *display) {
+	columns := mainProps.GetInt("visualizer.wall.columns", 6)
+	rows := mainProps.GetInt("visualizer.wall.rows", 3)
+	if columns < 1 || rows < 1 {
+		errorLogger.Println("Failed to load value for visualizer.wall.columns and visualizer.wall.rows. Must be at least 1")
+		return
+	}
+	featuring := mainProps.GetBool("visualizer.wall.featured", true)
+	featuredDuration := time.Duration(mainProps.GetFloat64("visualizer.wall.featured.seconds", 10) * float64(time.Second))
+	featureInterval := time.Duration(mainProps.GetFloat64("visualizer.wall.featured.interval.seconds", 30) * float64(time.Second))
+	if featuring && featureInterval <= featuredDuration {
+		errorLogger.Println("Failed to load value for visualizer.wall.featured.interval.seconds. Must be more than visualizer.wall.featured.seconds")
+		return
+	}
+	gameWall := &wall{
+		games:            games,
+		display:          gameDisplay,
+		colourSorted:     mainProps.GetString("visualizer.wall.order", "random") == colourWallOrder,
+		featured:         container.NewMax(),
+		featuredDuration: featuredDuration,
+	}
+	grid := container.New(&wallLayout{columns: columns, rows: rows})
+	for tileIndex := 0; tileIndex < columns*rows; tileIndex++ {
+		tile := newWallTile(tileIndex)
+		tile.container.Add(newLaunchArea(gameWall.launchTile(tile)))
+		gameWall.tiles = append(gameWall.tiles, tile)
+		gameWall.flipOrder = append(gameWall.flipOrder, tileIndex)
+		grid.Add(tile.container)
+	}
+	// Tiles are flipped in a random order so the wall does not change line by line
+	rand.Shuffle(len(gameWall.flipOrder), func(i, j int) {
+		gameWall.flipOrder[i], gameWall.flipOrder[j] = gameWall.flipOrder[j], gameWall.flipOrder[i]
+	})
+	gameWall.featured.Hide()
+	gameDisplay.window.SetContent(container.NewMax(grid, gameWall.featured))
+
+	flipInterval := time.Duration(mainProps.GetFloat64("visualizer.wall.flip.seconds", 3) * float64(time.Second))
+	go func() {
+		// Loading the first covers takes a while, the window is shown meanwhile and fills tile by tile
+		for _, tile := range gameWall.tiles {
+			gameWall.flipTile(tile)
+		}
+		for range time.Tick(flipInterval) {
+			// Pausing the slideshow holds the whole wall
+			if !gameDisplay.controller.Paused() {
+				gameWall.flipTile(gameWall.nextFlipTile())
+			}
+		}
+	}()
+	go gameWall.runFeatures(featuring, featureInterval)
+}
+
+// nextFlipTile returns the tile flipped the longest time ago and moves it to the end of the flip order.
+func (gameWall *wall) nextFlipTile() *wallTile {
+	gameWall.mutex.Lock()
+	defer gameWall.mutex.Unlock()
+	tileIndex := gameWall.flipOrder[0]
+	gameWall.flipOrder = append(gameWall.flipOrder[1:], tileIndex)
+	return gameWall.tiles[tileIndex]
+}
+
+// runFeatures features a random tile every feature interval and the games the controller of the display asks for, the
+// wall's slideshow. Without featuring only the games asked for are featured. The next feature starts the interval
+// after the last one started, counted from its hide so features never overlap however long loading takes.
+func (gameWall *wall) runFeatures(featuring bool, featureInterval time.Duration) {
+	pause := 24 * time.Hour
+	if featuring {
+		pause = featureInterval - gameWall.featuredDuration
+	}
+	for {
+		command, interrupted := gameWall.display.controller.Wait(pause)
+		if !interrupted {
+			if !featuring {
+				continue
+			}
+			command, interrupted = gameWall.featureTile(gameWall.randomTile())
+		}
+		// A command coming in while a game is featured acts right away
+		for interrupted {
+			command, interrupted = gameWall.handleCommand(command)
+		}
+	}
+}
+
+// handleCommand acts on a command of the controller like the slideshow of the cover mode does, the games shown are
+// featured over the wall. It returns the command interrupting the feature it started.
+func (gameWall *wall) handleCommand(command slideshow.Command) (slideshow.Command, bool) {
+	switch command.Kind {
+	case slideshow.Next:
+		return gameWall.featureTile(gameWall.randomTile())
+	case slideshow.Previous:
+		if previousKey, found := gameWall.display.controller.PreviousGame(); found {
+			return gameWall.featureGame(previousKey)
+		}
+	case slideshow.ShowGame:
+		return gameWall.featureGame(command.GameKey)
+	case slideshow.SetFilter:
+		setGameFilter(command.Filter)
+	}
+	return slideshow.Command{}, false
+}
+
+func (gameWall *wall) randomTile() *wallTile {
+	return gameWall.tiles[rand.Intn(len(gameWall.tiles))]
+}
+
+// featureGame features the game of the key. A game not on the wall is put on the tile flipped the longest time ago
+// first, even when the library filter excludes it.
+func (gameWall *wall) featureGame(key string) (slideshow.Command, bool) {
+	gameWall.mutex.Lock()
+	var shownTile *wallTile
+	for _, tile := range gameWall.tiles {
+		if tile.gameKey == key {
+			shownTile = tile
+		}
+	}
+	gameWall.mutex.Unlock()
+	if shownTile == nil {
+		gameIndex, game, gameFound := findGame(gameWall.games, key)
+		if !gameFound {
+			return slideshow.Command{}, false
+		}
+		shownTile = gameWall.nextFlipTile()
+		if !gameWall.setTileGame(shownTile, gameIndex, game) {
+			return slideshow.Command{}, false
+		}
+	}
+	return gameWall.featureTile(shownTile)
+}
+
+// flipTile turns the tile over to a game not on the wall yet. The tile keeps its game when every game is on the wall.
+func (gameWall *wall) flipTile(tile *wallTile) {
+	gameWall.mutex.Lock()
+	shownKeys := make(map[string]bool)
+	for _, shownTile := range gameWall.tiles {
+		shownKeys[shownTile.gameKey] = true
+	}
+	gameWall.mutex.Unlock()
+
+	var gameIndex int
+	var game domain.ClientGame
+	gameFound := false
+	if gameWall.colourSorted {
+		gameIndex, game, gameFound = gameWall.pickColourGame(tile.position, shownKeys)
+	}
+	if !gameFound {
+		gameIndex, game, gameFound = pickGame(gameWall.games, gameWall.display.selection, shownKeys)
+	}
+	if gameFound {
+		gameWall.setTileGame(tile, gameIndex, game)
+	}
+}
+
+// setTileGame turns the tile over to the cover of the game and tells if the game has a cover to show.
+func (gameWall *wall) setTileGame(tile *wallTile, gameIndex int, game domain.ClientGame) bool {
+	cover, coverFound := game.Cover()
+	if !coverFound {
+		return false
+	}
+	coverImage, imgErr := effectsCache.GetImage(cover.Url(), effectsConfig.Cover)
+	if imgErr != nil {
+		warnLogger.Println("Failed to load value image for game " + game.Name + "URL: " + cover.Url() + " - " + imgErr.Error())
+		return false
+	}
+	gameWall.mutex.Lock()
+	tile.gameIndex = gameIndex
+	tile.gameKey = game.Key()
+	tile.coverImage = coverImage
+	gameWall.mutex.Unlock()
+	tile.show(coverImage)
+	return true
+}
+
+// pickColourGame picks a game for the tile at the position among the games whose covers have the colours of that
+// part of the wall. The games matching the library filter are sorted by the most common colour of their covers and
+// each tile takes its games from its share of that order, games without cover palette coming last.
+func (gameWall *wall) pickColourGame(position int, excludedKeys map[string]bool) (int, domain.ClientGame, bool) {
+	var visibleIndexes []int
+	sortKeys := make(map[int]float64)
+	currentGameMutex.Lock()
+	for gameIndex, game := range gameWall.games {
+		if !gameFilter.Match(game) {
+			continue
+		}
+		visibleIndexes = append(visibleIndexes, gameIndex)
+		sortKeys[gameIndex] = 3
+		if cover, coverFound := game.Cover(); coverFound {
+			if coverPalette := palette.Parse(cover.Palette); len(coverPalette) > 0 {
+				sortKeys[gameIndex] = palette.SortKey(coverPalette[0])
+			}
+		}
+	}
+	currentGameMutex.Unlock()
+	sort.SliceStable(visibleIndexes, func(i, j int) bool {
+		return sortKeys[visibleIndexes[i]] < sortKeys[visibleIndexes[j]]
+	})
+
+	tileCount := len(gameWall.tiles)
+	shareStart := position * len(visibleIndexes) / tileCount
+	shareEnd := (position + 1) * len(visibleIndexes) / tileCount
+	var candidates []int
+	for _, gameIndex := range visibleIndexes[shareStart:shareEnd] {
+		if !excludedKeys[gameWall.games[gameIndex].Key()] {
+			candidates = append(candidates, gameIndex)
+		}
+	}
+	// Libraries smaller than the wall leave tiles without share, they are filled like an unsorted wall
+	if len(candidates) == 0 {
+		return 0, domain.ClientGame{}, false
+	}
+	gameIndex := candidates[rand.Intn(len(candidates))]
+	game := gameWall.games[gameIndex]
+	if localArtworks != nil {
+		game = localArtworks.Apply(game)
+	}
+	return gameIndex, game, true
+}
+
+// featureTile expands the game of the tile over the wall for a while. The featured game is the one launched and
+// marked by the game keys, and the one the controller reports as shown. It returns early with the command interrupting
+// it.
+func (gameWall *wall) featureTile(tile *wallTile) (slideshow.Command, bool) {
+	gameWall.mutex.Lock()
+	gameIndex := tile.gameIndex
+	coverImage := tile.coverImage
+	gameWall.mutex.Unlock()
+	if gameIndex < 0 {
+		return slideshow.Command{}, false
+	}
+	game := gameWall.games[gameIndex]
+	if localArtworks != nil {
+		game = localArtworks.Apply(game)
+	}
+
+	var overlayHideTime time.Time
+	if overlayConfig.HideAfter > 0 {
+		overlayHideTime = time.Now().Add(overlayConfig.HideAfter)
+	}
+	content, background, contentErr := newBackgroundContent(game, coverImage, overlayHideTime)
+	if contentErr != nil {
+		warnLogger.Println(contentErr.Error())
+		return slideshow.Command{}, false
+	}
+	setCurrentGame(&gameWall.games[gameIndex], gameWall.display)
+	gameWall.featured.Objects = []fyne.CanvasObject{content, gameWall.display.launchArea}
+	gameWall.featured.Refresh()
+	gameWall.featured.Show()
+	gameWall.display.controller.Shown(slideshow.NewEvent(game, background))
+	command, interrupted := gameWall.display.controller.Wait(gameWall.featuredDuration)
+	gameWall.featured.Hide()
+	return command, interrupted
+}
+
+// launchTile returns the action launching the game of the tile on a double click.
+func (gameWall *wall) launchTile(tile *wallTile) func() {
+	return func() {
+		gameWall.mutex.Lock()
+		gameIndex := tile.gameIndex
+		gameWall.mutex.Unlock()
+		if gameIndex >= 0 {
+			setCurrentGame(&gameWall.games[gameIndex], gameWall.display)
+			gameWall.display.launchArea.onLaunch()
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/compose"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/selection"
+	"vg-cover-screen-saver-go/internal/app/wallpaper"
+)
+
+// runWallpaper makes the compositions of the visualizer the desktop wallpaper, a new game every interval, without
+// opening a window.
+func runWallpaper(args []string) {
+	wallpaperConfig := wallpaper.LoadConfig(*mainProps)
+	wallpaperFlags := flag.NewFlagSet("wallpaper", flag.ExitOnError)
+	filterExpression := wallpaperFlags.String("filter", mainProps.GetString("visualizer.filter", "not hidden"),
+		"Only show the games matching the filter expression")
+	wallpaperFlags.StringVar(&wallpaperConfig.Setter, "setter", wallpaperConfig.Setter,
+		"Command setting the wallpaper, gsettings, feh, swaybg or custom for visualizer.wallpaper.command")
+	wallpaperFlags.DurationVar(&wallpaperConfig.Interval, "interval", wallpaperConfig.Interval,
+		"Time between wallpaper changes, e.g. 30m")
+	wallpaperFlags.IntVar(&wallpaperConfig.Width, "width", wallpaperConfig.Width,
+		"Width of the wallpaper in pixels, the monitor's when 0")
+	wallpaperFlags.IntVar(&wallpaperConfig.Height, "height", wallpaperConfig.Height,
+		"Height of the wallpaper in pixels, the monitor's when 0")
+	displayMode := wallpaperFlags.String("mode", mainProps.GetString("visualizer.display.mode", coverDisplayMode),
+		"Composition of the wallpaper, cover or logo")
+	once := wallpaperFlags.Bool("once", false, "Set one wallpaper and exit, e.g. to be run by cron")
+	wallpaperFlags.Parse(args)
+
+	wallpaperErr := rotateWallpapers(*filterExpression, *displayMode, *once, wallpaperConfig)
+	if wallpaperErr != nil {
+		fmt.Println("Setting wallpaper failed! " + wallpaperErr.Error())
+		errorLogger.Println("Failed to set wallpaper: " + wallpaperErr.Error())
+		os.Exit(1)
+	}
+}
+
+func rotateWallpapers(filterExpression string, displayMode string, once bool, wallpaperConfig wallpaper.Config) error {
+	if !once && wallpaperConfig.Interval <= 0 {
+		return errors.New("Wallpaper interval must be more than 0, check visualizer.wallpaper.interval.minutes and -interval")
+	}
+	setter, setterErr := wallpaper.NewSetter(wallpaperConfig.Setter, wallpaperConfig.Command)
+	if setterErr != nil {
+		return setterErr
+	}
+	renderConfig := getWallpaperSize(wallpaperConfig)
+	directory, absErr := filepath.Abs(wallpaperConfig.Directory)
+	if absErr != nil {
+		return absErr
+	}
+	mkdirErr := os.MkdirAll(directory, 0755)
+	if mkdirErr != nil {
+		return mkdirErr
+	}
+	games, gamesErr := loadRenderGames(filterExpression)
+	if gamesErr != nil {
+		return gamesErr
+	}
+	// Like a render the persisted slideshow state is left alone
+	wallpaperSelection := selection.New(
+		mainProps.GetString("visualizer.selection.strategy", "random"),
+		mainProps.GetInt("visualizer.selection.recency.size", 20),
+		nil)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	for wallpaperNumber := 0; ; wallpaperNumber++ {
+		// Setters like gsettings ignore a wallpaper set to the path they already show, so two files take turns
+		wallpaperPath := filepath.Join(directory, "wallpaper-"+strconv.Itoa(wallpaperNumber%2)+".png")
+		game, wallpaperErr := writeWallpaper(games, wallpaperSelection, displayMode, renderConfig, wallpaperPath)
+		if wallpaperErr == nil {
+			wallpaperErr = setter.Set(wallpaperPath)
+		}
+		if once {
+			if wallpaperErr == nil {
+				fmt.Println(game.Name)
+			}
+			return wallpaperErr
+		}
+		if wallpaperErr != nil {
+			warnLogger.Println("Failed to set wallpaper: " + wallpaperErr.Error())
+		} else {
+			fmt.Println(game.Name)
+		}
+
+		select {
+		case <-time.After(wallpaperConfig.Interval):
+		case <-stop:
+			setter.Close()
+			return nil
+		}
+		// Games synced or hidden by the visualizer in the meantime are picked up for the next wallpaper
+		if reloadedGames, reloadErr := loadRenderGames(filterExpression); reloadErr == nil {
+			games = reloadedGames
+		} else {
+			warnLogger.Println("Failed to reload games, keeping the games loaded before: " + reloadErr.Error())
+		}
+	}
+}
+
+// getWallpaperSize returns the configured size, or the resolution of the monitor when none is configured. Without
+// monitor found the render size is used.
+func getWallpaperSize(wallpaperConfig wallpaper.Config) compose.Config {
+	if wallpaperConfig.Width > 0 && wallpaperConfig.Height > 0 {
+		return compose.Config{Width: wallpaperConfig.Width, Height: wallpaperConfig.Height}
+	}
+	width, height, sizeErr := wallpaper.ScreenSize()
+	if sizeErr != nil {
+		renderConfig := compose.LoadConfig(*mainProps)
+		warnLogger.Println("Failed to detect screen size, using the render size " + strconv.Itoa(renderConfig.Width) +
+			"x" + strconv.Itoa(renderConfig.Height) + ": " + sizeErr.Error())
+		return renderConfig
+	}
+	return compose.Config{Width: width, Height: height}
+}
+
+// writeWallpaper composes the next picked game and writes it to the path. Games that cannot be composed are skipped
+// for the next one.
+func writeWallpaper(games []domain.ClientGame, wallpaperSelection selection.Strategy, displayMode string, renderConfig compose.Config, wallpaperPath string) (domain.ClientGame, error) {
+	var composeErr error
+	for attempt := 0; attempt < len(games); attempt++ {
+		game := games[wallpaperSelection.Next(games, nil)]
+		frame, frameErr := composeFrame(game, displayMode, -1, renderConfig)
+		if frameErr != nil {
+			composeErr = frameErr
+			continue
+		}
+		// The wallpaper is written next to its path first so a setter never reads a half written file
+		tempPath := wallpaperPath + ".tmp"
+		writeErr := writePng(tempPath, frame)
+		if writeErr != nil {
+			return game, writeErr
+		}
+		return game, os.Rename(tempPath, wallpaperPath)
+	}
+	return domain.ClientGame{}, composeErr
+}
@@ -5,10 +5,13 @@ go 1.16
 require (
 	fyne.io/fyne/v2 v2.1.2
 	github.com/avast/retry-go/v4 v4.0.1
+	github.com/eclipse/paho.mqtt.golang v1.3.5
 	github.com/esimov/stackblur-go v1.0.2
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/go-resty/resty/v2 v2.7.0
 	github.com/lithammer/fuzzysearch v1.1.3
 	github.com/magiconair/properties v1.8.5
 	github.com/mitchellh/mapstructure v1.4.3
 	github.com/tidwall/buntdb v1.2.6
+	golang.org/x/image v0.0.0-20200430140353-33d19683fad8
 )
@@ -0,0 +1,54 @@
+package artwork
+
+import (
+	"strconv"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+// Dedupe removes the artworks already in the list. IGDB ids are only unique per artwork type, so artworks are the same
+// when they have the same provider, type and id, or the same image URL.
+func Dedupe(artworks []domain.GameArtwork) []domain.GameArtwork {
+	seenIds := make(map[string]bool)
+	seenImages := make(map[string]bool)
+	var dedupedArtworks []domain.GameArtwork
+	for _, artwork := range artworks {
+		id := artwork.GetProvider() + artwork.Type.String() + strconv.Itoa(artwork.Id)
+		if (artwork.Id != 0 && seenIds[id]) || seenImages[artwork.Url()] {
+			continue
+		}
+		seenIds[id] = true
+		seenImages[artwork.Url()] = true
+		dedupedArtworks = append(dedupedArtworks, artwork)
+	}
+	return dedupedArtworks
+}
+
+// Provider fetches the artworks of a game from one artwork source.
+type Provider func(game domain.ClientGame) ([]domain.GameArtwork, error)
+
+// SortByProvider orders the artworks by the position of their provider in the priority list, so the cover and
+// backgrounds of the first provider are preferred. Artworks of providers missing from the list are dropped.
+func SortByProvider(artworks []domain.GameArtwork, priority []string) []domain.GameArtwork {
+	var sortedArtworks []domain.GameArtwork
+	for _, provider := range priority {
+		for _, artwork := range artworks {
+			if artwork.GetProvider() == provider {
+				sortedArtworks = append(sortedArtworks, artwork)
+			}
+		}
+	}
+	return sortedArtworks
+}
+
+// ParsePriority reads a comma separated provider list such as igdb,steam.
+func ParsePriority(providers string) []string {
+	var priority []string
+	for _, provider := range strings.Split(providers, ",") {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider != "" {
+			priority = append(priority, provider)
+		}
+	}
+	return priority
+}
@@ -0,0 +1,101 @@
+package artwork
+
+import (
+	"image"
+	"math/bits"
+	"strconv"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+// ImageLoader returns the decoded image of an artwork URL.
+type ImageLoader interface {
+	GetImage(imageUrl string) (image.Image, error)
+}
+
+// DedupeSimilar removes the artworks that look like an artwork earlier in the list, such as the same screenshot uploaded
+// as artwork or at another resolution. Images are compared by perceptual hash, artworks are similar when their hashes
+// differ by at most maxDistance bits. The hashes are stored on the artworks so they are only computed once. Artworks
+// that cannot be loaded are kept.
+func DedupeSimilar(artworks []domain.GameArtwork, loader ImageLoader, maxDistance int) []domain.GameArtwork {
+	var keptArtworks []domain.GameArtwork
+	var keptHashes []uint64
+	for _, artwork := range artworks {
+		hash, hashErr := getHash(&artwork, loader)
+		if hashErr != nil {
+			keptArtworks = append(keptArtworks, artwork)
+			continue
+		}
+		similar := false
+		for _, keptHash := range keptHashes {
+			if bits.OnesCount64(hash^keptHash) <= maxDistance {
+				similar = true
+				break
+			}
+		}
+		if !similar {
+			keptArtworks = append(keptArtworks, artwork)
+			keptHashes = append(keptHashes, hash)
+		}
+	}
+	return keptArtworks
+}
+
+func getHash(artwork *domain.GameArtwork, loader ImageLoader) (uint64, error) {
+	if artwork.PerceptualHash != "" {
+		return strconv.ParseUint(artwork.PerceptualHash, 16, 64)
+	}
+	img, loadErr := loader.GetImage(artwork.Url())
+	if loadErr != nil {
+		return 0, loadErr
+	}
+	hash := PerceptualHash(img)
+	artwork.PerceptualHash = strconv.FormatUint(hash, 16)
+	return hash, nil
+}
+
+// PerceptualHash is a difference hash of the image. The image is shrunk to a 9x8 grid of average brightness and each
+// bit tells if a cell is brighter than its right neighbour, which survives resizing and recompression.
+func PerceptualHash(img image.Image) uint64 {
+	const width, height = 9, 8
+	var brightness [height][width]float64
+	bounds := img.Bounds()
+	for cellY := 0; cellY < height; cellY++ {
+		for cellX := 0; cellX < width; cellX++ {
+			minX := bounds.Min.X + cellX*bounds.Dx()/width
+			maxX := bounds.Min.X + (cellX+1)*bounds.Dx()/width
+			minY := bounds.Min.Y + cellY*bounds.Dy()/height
+			maxY := bounds.Min.Y + (cellY+1)*bounds.Dy()/height
+			brightness[cellY][cellX] = getAverageBrightness(img, minX, maxX, minY, maxY)
+		}
+	}
+
+	var hash uint64
+	for cellY := 0; cellY < height; cellY++ {
+		for cellX := 0; cellX < width-1; cellX++ {
+			hash <<= 1
+			if brightness[cellY][cellX] > brightness[cellY][cellX+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+func getAverageBrightness(img image.Image, minX int, maxX int, minY int, maxY int) float64 {
+	// Sampling at most 16x16 points per cell keeps hashing large artworks fast
+	stepX := (maxX-minX)/16 + 1
+	stepY := (maxY-minY)/16 + 1
+	total := 0.0
+	count := 0
+	for y := minY; y < maxY; y += stepY {
+		for x := minX; x < maxX; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			total += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
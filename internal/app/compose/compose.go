@@ -0,0 +1,122 @@
+package compose
+
+import (
+	"github.com/magiconair/properties"
+	xdraw "golang.org/x/image/draw"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// Config is the size of the composed frames.
+type Config struct {
+	Width  int
+	Height int
+}
+
+func LoadConfig(props properties.Properties) Config {
+	return Config{
+		Width:  props.GetInt("visualizer.render.width", 1920),
+		Height: props.GetInt("visualizer.render.height", 1080),
+	}
+}
+
+// CoverFrame composes the cover mode of the visualizer: the cover as big as the frame allows in front of the
+// background stretched over the whole frame, both with their effects already applied. Without background the cover is
+// shown on black.
+func CoverFrame(cover image.Image, background image.Image, config Config) *image.RGBA {
+	frame := newFrame(config)
+	if background != nil {
+		xdraw.ApproxBiLinear.Scale(frame, frame.Bounds(), background, background.Bounds(), draw.Src, nil)
+	}
+	coverRect := FitRect(cover.Bounds().Size(), frame.Bounds().Size())
+	xdraw.CatmullRom.Scale(frame, coverRect, cover, cover.Bounds(), draw.Over, nil)
+	return frame
+}
+
+// LogoFrame composes the logo mode of the visualizer: the transparent logo placed over the unblurred hero filling the
+// whole frame. Position and width factor are the ones of LogoRect.
+func LogoFrame(hero image.Image, logo image.Image, position string, widthFactor float64, config Config) *image.RGBA {
+	frame := newFrame(config)
+	filledHero := CropToFill(hero, frame.Bounds().Dx(), frame.Bounds().Dy())
+	xdraw.ApproxBiLinear.Scale(frame, frame.Bounds(), filledHero, filledHero.Bounds(), draw.Src, nil)
+	logoRect := LogoRect(logo.Bounds().Size(), frame.Bounds().Size(), position, widthFactor)
+	xdraw.CatmullRom.Scale(frame, logoRect, logo, logo.Bounds(), draw.Over, nil)
+	return frame
+}
+
+func newFrame(config Config) *image.RGBA {
+	frame := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	return frame
+}
+
+// CropToFill cuts the middle of the image to the aspect ratio of the width and height, so stretching it over that size
+// does not distort it.
+func CropToFill(img image.Image, width int, height int) image.Image {
+	bounds := img.Bounds()
+	if width <= 0 || height <= 0 || bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return img
+	}
+	targetRatio := float64(width) / float64(height)
+	cropWidth, cropHeight := bounds.Dx(), bounds.Dy()
+	if float64(bounds.Dx())/float64(bounds.Dy()) > targetRatio {
+		cropWidth = int(float64(bounds.Dy()) * targetRatio)
+	} else {
+		cropHeight = int(float64(bounds.Dx()) / targetRatio)
+	}
+	cropMin := image.Pt(bounds.Min.X+(bounds.Dx()-cropWidth)/2, bounds.Min.Y+(bounds.Dy()-cropHeight)/2)
+	cropped := image.NewRGBA(image.Rect(0, 0, cropWidth, cropHeight))
+	draw.Draw(cropped, cropped.Bounds(), img, cropMin, draw.Src)
+	return cropped
+}
+
+// FitRect is the biggest rectangle of the image's aspect ratio fitting in the frame, centered in the frame.
+func FitRect(imageSize image.Point, frameSize image.Point) image.Rectangle {
+	if imageSize.X == 0 || imageSize.Y == 0 {
+		return image.Rectangle{}
+	}
+	width, height := frameSize.X, frameSize.X*imageSize.Y/imageSize.X
+	if height > frameSize.Y {
+		width, height = frameSize.Y*imageSize.X/imageSize.Y, frameSize.Y
+	}
+	x, y := (frameSize.X-width)/2, (frameSize.Y-height)/2
+	return image.Rect(x, y, x+width, y+height)
+}
+
+// LogoRect places a logo in the frame. The logo is widthFactor of the frame width wide and keeps its aspect ratio,
+// capped at half the frame height. The position is center or top or bottom followed by left, center or right, e.g.
+// bottom-left, with a margin to the frame border.
+func LogoRect(logoSize image.Point, frameSize image.Point, position string, widthFactor float64) image.Rectangle {
+	if logoSize.X == 0 || logoSize.Y == 0 {
+		return image.Rectangle{}
+	}
+	x, y, width, height := LogoPlacement(float64(logoSize.X)/float64(logoSize.Y), float64(frameSize.X), float64(frameSize.Y), position, widthFactor)
+	return image.Rect(int(x), int(y), int(x+width), int(y+height))
+}
+
+// LogoPlacement is LogoRect in floating point for layouts not working in pixels.
+func LogoPlacement(aspectRatio float64, frameWidth float64, frameHeight float64, position string, widthFactor float64) (float64, float64, float64, float64) {
+	margin := frameHeight / 20
+	width, height := frameWidth*widthFactor, frameWidth*widthFactor/aspectRatio
+	// A very tall logo could leave the frame, so its height is capped
+	if height > frameHeight/2 {
+		width, height = frameHeight/2*aspectRatio, frameHeight/2
+	}
+
+	position = strings.ToLower(position)
+	x := (frameWidth - width) / 2
+	if strings.HasSuffix(position, "left") {
+		x = margin
+	} else if strings.HasSuffix(position, "right") {
+		x = frameWidth - width - margin
+	}
+	y := (frameHeight - height) / 2
+	if strings.HasPrefix(position, "top") {
+		y = margin
+	} else if strings.HasPrefix(position, "bottom") {
+		y = frameHeight - height - margin
+	}
+	return x, y, width, height
+}
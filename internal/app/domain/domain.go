@@ -1,12 +1,74 @@
 package domain
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 type ClientGame struct {
-	Name        string            `json:"name"`
-	Source      GameSource        `json:"source"`
-	SourceId    string            `json:"source-id"`
-	Description string            `json:"description"`
-	Developers  []string          `json:"developers"`
-	Artworks    []IgdbGameArtwork `json:"artworks"`
+	Name            string        `json:"name"`
+	Source          GameSource    `json:"source"`
+	SourceId        string        `json:"source-id"`
+	Description     string        `json:"description"`
+	Developers      []string      `json:"developers"`
+	Artworks        []GameArtwork `json:"artworks"`
+	LastLaunched    time.Time     `json:"last-launched"`
+	LaunchCount     int           `json:"launch-count"`
+	PlaytimeForever int           `json:"playtime-forever"`
+	Playtime2Weeks  int           `json:"playtime-2weeks"`
+	PlaytimeWindows int           `json:"playtime-windows"`
+	PlaytimeMac     int           `json:"playtime-mac"`
+	PlaytimeLinux   int           `json:"playtime-linux"`
+	LastPlayed      time.Time     `json:"last-played"`
+	Favourite       bool          `json:"favourite"`
+	Hidden          bool          `json:"hidden"`
+	// ArtworksFetched is when the artwork providers were last asked for the artworks of the game
+	ArtworksFetched time.Time `json:"artworks-fetched"`
+	// Metadata fetched from IGDB
+	IgdbId            int               `json:"igdb-id"`
+	Genres            []string          `json:"genres"`
+	Themes            []string          `json:"themes"`
+	GameModes         []string          `json:"game-modes"`
+	FirstReleaseDate  time.Time         `json:"first-release-date"`
+	AggregatedRating  float64           `json:"aggregated-rating"`
+	UserRating        float64           `json:"user-rating"`
+	Summary           string            `json:"summary"`
+	Storyline         string            `json:"storyline"`
+	Franchise         string            `json:"franchise"`
+	Collection        string            `json:"collection"`
+	InvolvedCompanies []InvolvedCompany `json:"involved-companies"`
+	AgeRatings        []AgeRating       `json:"age-ratings"`
+}
+
+type InvolvedCompany struct {
+	Name      string `json:"name"`
+	Developer bool   `json:"developer"`
+	Publisher bool   `json:"publisher"`
+}
+
+// AgeRating is the rating of one rating organization, e.g. PEGI 12. Age is the minimum age the rating stands for, or
+// -1 when the rating has no age such as ESRB RP.
+type AgeRating struct {
+	Organization string `json:"organization"`
+	Rating       string `json:"rating"`
+	Age          int    `json:"age"`
+}
+
+// Key is the DB key the game is stored under.
+func (game ClientGame) Key() string {
+	return game.Source.String() + game.SourceId
+}
+
+// FormatPlaytime formats a playtime in minutes the way Steam reports it.
+func FormatPlaytime(minutes int) string {
+	if minutes == 0 {
+		return "Never played"
+	}
+	if minutes < 120 {
+		return fmt.Sprintf("%d minutes played", minutes)
+	}
+	return fmt.Sprintf("%.1f hours played", float64(minutes)/60)
 }
 
 type GameSource int
@@ -29,9 +91,123 @@ func (gameSource GameSource) String() string {
 	return "UnknownGameSource"
 }
 
-type IgdbGameArtwork struct {
-	Id        int    `json:"id"`
-	ArtworkId string `json:"image_id"`
+// ParseGameSource returns the source by its name ignoring case, UnknownGameSource for unknown names.
+func ParseGameSource(name string) GameSource {
+	for _, gameSource := range []GameSource{Steam, ItchIo} {
+		if strings.EqualFold(gameSource.String(), name) {
+			return gameSource
+		}
+	}
+	return UnknownGameSource
+}
+
+// GameArtwork is an image of the game from one of the artwork providers. IGDB artworks are identified by their image
+// id, the artworks of other providers by their URL.
+type GameArtwork struct {
+	Id           int         `json:"id"`
+	ArtworkId    string      `json:"image_id"`
+	Type         ArtworkType `json:"type"`
+	Width        int         `json:"width"`
+	Height       int         `json:"height"`
+	Animated     bool        `json:"animated"`
+	AlphaChannel bool        `json:"alpha_channel"`
+	// PerceptualHash is the hex difference hash of the image, used to find near identical artworks
+	PerceptualHash string `json:"phash"`
+	// Palette is the dominant colours of the image as hex RGB, the most common first
+	Palette  []string `json:"palette"`
+	Provider string   `json:"provider"`
+	ImageUrl string   `json:"image-url"`
+}
+
+const (
+	IgdbProvider        = "igdb"
+	SteamProvider       = "steam"
+	SteamGridDbProvider = "steamgriddb"
+	LocalProvider       = "local"
+)
+
+func (artwork GameArtwork) Url() string {
+	if artwork.ImageUrl != "" {
+		return artwork.ImageUrl
+	}
+	return "https://images.igdb.com/igdb/image/upload/t_original/" + artwork.ArtworkId + ".jpg"
+}
+
+// GetProvider returns the provider of the artwork. Artworks stored before other providers were added come from IGDB.
+func (artwork GameArtwork) GetProvider() string {
+	if artwork.Provider == "" {
+		return IgdbProvider
+	}
+	return artwork.Provider
+}
+
+// IsPortrait is true for artworks higher than wide. Artworks stored without their size are neither portrait nor
+// landscape.
+func (artwork GameArtwork) IsPortrait() bool {
+	return artwork.Height > artwork.Width
+}
+
+func (artwork GameArtwork) IsLandscape() bool {
+	return artwork.Width > 0 && artwork.Width >= artwork.Height
+}
+
+// Cover returns the artwork shown in the foreground. Covers come first, then any portrait artwork. Games stored before
+// artwork types were recorded have their cover first in the list.
+func (game ClientGame) Cover() (GameArtwork, bool) {
+	for _, artwork := range game.Artworks {
+		if artwork.Type == Cover {
+			return artwork, true
+		}
+	}
+	for _, artwork := range game.Artworks {
+		if artwork.IsPortrait() && !artwork.Animated {
+			return artwork, true
+		}
+	}
+	if len(game.Artworks) > 0 && game.Artworks[0].Type == UnknownArtworkType {
+		return game.Artworks[0], true
+	}
+	return GameArtwork{}, false
+}
+
+// Backgrounds returns the artworks, screenshots and heroes wide enough to fill the window behind the cover. Artworks
+// without a known size are expected to be wide, as only covers are usually portrait.
+func (game ClientGame) Backgrounds() []GameArtwork {
+	cover, _ := game.Cover()
+	var backgrounds []GameArtwork
+	for _, artwork := range game.Artworks {
+		if artwork.Type == Cover || artwork.Type == Logo || artwork.Type == Icon || artwork.Animated || artwork.Url() == cover.Url() {
+			continue
+		}
+		if artwork.IsLandscape() || artwork.Width == 0 {
+			backgrounds = append(backgrounds, artwork)
+		}
+	}
+	return backgrounds
+}
+
+// Logo returns the transparent logo of the game shown over its hero in logo mode.
+func (game ClientGame) Logo() (GameArtwork, bool) {
+	for _, artwork := range game.Artworks {
+		if artwork.Type == Logo && !artwork.Animated {
+			return artwork, true
+		}
+	}
+	return GameArtwork{}, false
+}
+
+// Heroes returns the wide hero images of the game, or its other backgrounds when it has no hero.
+func (game ClientGame) Heroes() []GameArtwork {
+	var heroes []GameArtwork
+	for _, artwork := range game.Artworks {
+		if artwork.Type == Hero && !artwork.Animated {
+			heroes = append(heroes, artwork)
+		}
+	}
+	if len(heroes) == 0 {
+		return game.Backgrounds()
+	}
+	return heroes
 }
 
 type ArtworkType int
@@ -41,6 +217,9 @@ const (
 	Artwork
 	Cover
 	ScreenShot
+	Hero
+	Logo
+	Icon
 )
 
 func (artType ArtworkType) String() string {
@@ -51,6 +230,12 @@ func (artType ArtworkType) String() string {
 		return "covers"
 	case ScreenShot:
 		return "screenshots"
+	case Hero:
+		return "heroes"
+	case Logo:
+		return "logos"
+	case Icon:
+		return "icons"
 	case UnknownArtworkType:
 		return "UnknownArtworkType"
 	}
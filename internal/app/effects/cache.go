@@ -0,0 +1,148 @@
+package effects
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// ImageLoader returns the decoded image of an artwork URL.
+type ImageLoader interface {
+	GetImage(imageUrl string) (image.Image, error)
+}
+
+// Cache keeps the artworks with the effects of a chain applied on disk, by artwork and chain hash, so the effects are
+// only computed once. The most recently used results are kept in memory as well. Changing a chain changes its hash,
+// the results of the old chain are removed when the cache is created next.
+type Cache struct {
+	loader       ImageLoader
+	directory    string
+	maxProcessed int
+	mutex        sync.Mutex
+	processed    map[string]*list.Element
+	recent       *list.List
+}
+
+type processedImage struct {
+	key   string
+	image image.Image
+}
+
+// cachedFileName is the name of a result on disk, the hex SHA-1 of the artwork URL and the chain hash.
+var cachedFileName = regexp.MustCompile(`^[0-9a-f]{40}-([0-9a-f]{16})\.png$`)
+
+// NewCache creates the cache of the results of the chains in use and removes the results of all other chains from the
+// directory. Files that cannot be removed are left for the next time.
+func NewCache(loader ImageLoader, directory string, maxProcessed int, chains ...Chain) *Cache {
+	pruneResults(directory, chains)
+	return &Cache{
+		loader:       loader,
+		directory:    directory,
+		maxProcessed: maxProcessed,
+		processed:    make(map[string]*list.Element),
+		recent:       list.New(),
+	}
+}
+
+// GetImage returns the image of the URL with the effects of the chain applied. An empty chain returns the image as
+// the loader does.
+func (cache *Cache) GetImage(imageUrl string, chain Chain) (image.Image, error) {
+	if chain.Empty() {
+		return cache.loader.GetImage(imageUrl)
+	}
+	urlHash := sha1.Sum([]byte(imageUrl))
+	key := hex.EncodeToString(urlHash[:]) + "-" + chain.Hash()
+	cache.mutex.Lock()
+	if element, found := cache.processed[key]; found {
+		cache.recent.MoveToFront(element)
+		cache.mutex.Unlock()
+		return element.Value.(*processedImage).image, nil
+	}
+	cache.mutex.Unlock()
+
+	imagePath := filepath.Join(cache.directory, key+".png")
+	processed, readErr := readPng(imagePath)
+	if readErr != nil {
+		source, loadErr := cache.loader.GetImage(imageUrl)
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		var applyErr error
+		processed, applyErr = chain.Apply(source)
+		if applyErr != nil {
+			return nil, applyErr
+		}
+		// A result that cannot be written is only computed again next time, it is still shown
+		cache.writePng(imagePath, processed)
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if _, found := cache.processed[key]; !found {
+		cache.processed[key] = cache.recent.PushFront(&processedImage{key: key, image: processed})
+		for cache.recent.Len() > cache.maxProcessed {
+			oldest := cache.recent.Back()
+			cache.recent.Remove(oldest)
+			delete(cache.processed, oldest.Value.(*processedImage).key)
+		}
+	}
+	return processed, nil
+}
+
+func pruneResults(directory string, chains []Chain) {
+	chainHashes := make(map[string]bool)
+	for _, chain := range chains {
+		chainHashes[chain.Hash()] = true
+	}
+	entries, readErr := os.ReadDir(directory)
+	if readErr != nil {
+		return
+	}
+	for _, entry := range entries {
+		match := cachedFileName.FindStringSubmatch(entry.Name())
+		if match != nil && !chainHashes[match[1]] {
+			os.Remove(filepath.Join(directory, entry.Name()))
+		}
+	}
+}
+
+func readPng(path string) (image.Image, error) {
+	imageFile, openErr := os.Open(path)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer imageFile.Close()
+	return png.Decode(imageFile)
+}
+
+// writePng writes the image to a temporary file first so an interrupted write is never taken for a cached result.
+func (cache *Cache) writePng(path string, img image.Image) error {
+	mkdirErr := os.MkdirAll(cache.directory, 0755)
+	if mkdirErr != nil {
+		return mkdirErr
+	}
+	tempFile, tempErr := os.CreateTemp(cache.directory, "effects-*")
+	if tempErr != nil {
+		return tempErr
+	}
+	encodeErr := png.Encode(tempFile, img)
+	closeErr := tempFile.Close()
+	if encodeErr != nil || closeErr != nil {
+		os.Remove(tempFile.Name())
+		if encodeErr != nil {
+			return encodeErr
+		}
+		return closeErr
+	}
+	renameErr := os.Rename(tempFile.Name(), path)
+	if renameErr != nil {
+		os.Remove(tempFile.Name())
+	}
+	return renameErr
+}
@@ -0,0 +1,60 @@
+package effects
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type uniformLoader struct{}
+
+func (uniformLoader) GetImage(imageUrl string) (image.Image, error) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for index := range img.Pix {
+		img.Pix[index] = 200
+	}
+	return img, nil
+}
+
+func TestNewCachePrunesOtherChains(t *testing.T) {
+	directory := t.TempDir()
+	oldChain, _ := Parse("blur:20")
+	keptChain, _ := Parse("blur:12,desaturate")
+	oldCache := NewCache(uniformLoader{}, directory, 4, oldChain, keptChain)
+	for _, chain := range []Chain{oldChain, keptChain} {
+		if _, err := oldCache.GetImage("http://artwork/1.png", chain); err != nil {
+			t.Fatalf("GetImage failed: %v", err)
+		}
+	}
+	unrelatedPath := filepath.Join(directory, "notes.png")
+	if err := os.WriteFile(unrelatedPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	NewCache(uniformLoader{}, directory, 4, keptChain)
+	entries, _ := os.ReadDir(directory)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 2 {
+		t.Fatalf("files after pruning = %v, want the kept chain's result and notes.png", names)
+	}
+	for _, name := range names {
+		if match := cachedFileName.FindStringSubmatch(name); match != nil && match[1] != keptChain.Hash() {
+			t.Errorf("result %s of another chain left, want only %s results", name, keptChain.Hash())
+		}
+	}
+
+	// The kept result is read back rather than computed again
+	keptCache := NewCache(nil, directory, 4, keptChain)
+	img, err := keptCache.GetImage("http://artwork/1.png", keptChain)
+	if err != nil {
+		t.Fatalf("GetImage of the kept result failed: %v", err)
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA); got.A == 0 {
+		t.Errorf("kept result pixel = %v, want the cached image", got)
+	}
+}
@@ -0,0 +1,293 @@
+package effects
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"github.com/esimov/stackblur-go"
+	"github.com/magiconair/properties"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/palette"
+)
+
+// Config is the effect chains of the backgrounds and the covers and where their results are cached.
+type Config struct {
+	Background     Chain
+	Cover          Chain
+	CacheDirectory string
+	MaxProcessed   int
+}
+
+func LoadConfig(props properties.Properties) (Config, error) {
+	background, backgroundErr := Parse(props.GetString("visualizer.effects.background", "blur:20"))
+	if backgroundErr != nil {
+		return Config{}, errors.New("Invalid visualizer.effects.background: " + backgroundErr.Error())
+	}
+	cover, coverErr := Parse(props.GetString("visualizer.effects.cover", ""))
+	if coverErr != nil {
+		return Config{}, errors.New("Invalid visualizer.effects.cover: " + coverErr.Error())
+	}
+	return Config{
+		Background:     background,
+		Cover:          cover,
+		CacheDirectory: props.GetString("visualizer.effects.cache.directory", "image_cache/effects"),
+		MaxProcessed:   props.GetInt("visualizer.effects.cache.processed", 16),
+	}, nil
+}
+
+// effect returns a changed copy of the image. The image given is shared with the image cache and must not be changed.
+type effect func(img image.Image) (image.Image, error)
+
+// Chain is an ordered list of effects, each applied to the result of the one before, e.g. blur:20,darken:0.3.
+type Chain struct {
+	spec    string
+	effects []effect
+}
+
+// Parse reads a comma separated chain of effects, each the effect name followed by its arguments separated by colons,
+// e.g. tint:#ff8800:0.3. Arguments left out take their defaults.
+func Parse(spec string) (Chain, error) {
+	var entries []string
+	var chainEffects []effect
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		arguments := strings.Split(entry, ":")
+		parsedEffect, parseErr := parseEffect(arguments[0], arguments[1:])
+		if parseErr != nil {
+			return Chain{}, errors.New("Invalid effect " + entry + ": " + parseErr.Error())
+		}
+		entries = append(entries, entry)
+		chainEffects = append(chainEffects, parsedEffect)
+	}
+	return Chain{spec: strings.Join(entries, ","), effects: chainEffects}, nil
+}
+
+func parseEffect(name string, arguments []string) (effect, error) {
+	switch name {
+	case "blur":
+		radius, radiusErr := getNumber(arguments, 0, 20)
+		// The stack blur fails on a radius of 0 and its lookup tables end at 254
+		if radiusErr != nil || radius < 1 || radius > 254 {
+			return nil, errors.New("radius must be a number of pixels from 1 to 254")
+		}
+		return func(img image.Image) (image.Image, error) {
+			// The stack blur works in place on NRGBA images, so it gets a copy
+			return stackblur.Run(toNRGBA(img), uint32(radius))
+		}, nil
+	case "darken", "brighten", "desaturate":
+		defaultAmount := 0.3
+		if name == "desaturate" {
+			defaultAmount = 1
+		}
+		amount, amountErr := getAmount(arguments, 0, defaultAmount)
+		if amountErr != nil {
+			return nil, amountErr
+		}
+		return func(img image.Image) (image.Image, error) {
+			return mapPixels(img, func(pixel color.NRGBA) color.NRGBA {
+				switch name {
+				case "darken":
+					return mix(pixel, color.NRGBA{A: pixel.A}, amount)
+				case "brighten":
+					return mix(pixel, color.NRGBA{R: 255, G: 255, B: 255, A: pixel.A}, amount)
+				}
+				grey := uint8(brightness(pixel) + 0.5)
+				return mix(pixel, color.NRGBA{R: grey, G: grey, B: grey, A: pixel.A}, amount)
+			}), nil
+		}, nil
+	case "duotone":
+		colours := palette.Parse(arguments)
+		if len(arguments) != 2 || len(colours) != 2 {
+			return nil, errors.New("duotone needs two hex colours, e.g. duotone:#1a1a40:#f0c080")
+		}
+		return func(img image.Image) (image.Image, error) {
+			return mapPixels(img, func(pixel color.NRGBA) color.NRGBA {
+				toned := mix(colours[0], colours[1], brightness(pixel)/255)
+				toned.A = pixel.A
+				return toned
+			}), nil
+		}, nil
+	case "tint":
+		colours := palette.Parse(arguments[:minInt(len(arguments), 1)])
+		if len(colours) != 1 {
+			return nil, errors.New("tint needs a hex colour, e.g. tint:#ff8800:0.3")
+		}
+		amount, amountErr := getAmount(arguments, 1, 0.3)
+		if amountErr != nil {
+			return nil, amountErr
+		}
+		return func(img image.Image) (image.Image, error) {
+			return mapPixels(img, func(pixel color.NRGBA) color.NRGBA {
+				tint := colours[0]
+				tint.A = pixel.A
+				return mix(pixel, tint, amount)
+			}), nil
+		}, nil
+	case "vignette":
+		strength, strengthErr := getAmount(arguments, 0, 0.5)
+		if strengthErr != nil {
+			return nil, strengthErr
+		}
+		return func(img image.Image) (image.Image, error) {
+			return vignette(img, strength), nil
+		}, nil
+	case "grain":
+		amount, amountErr := getAmount(arguments, 0, 0.08)
+		if amountErr != nil {
+			return nil, amountErr
+		}
+		return func(img image.Image) (image.Image, error) {
+			// A fixed seed gives the same grain each time, so cached and fresh results look alike
+			random := rand.New(rand.NewSource(1))
+			return mapPixels(img, func(pixel color.NRGBA) color.NRGBA {
+				noise := (random.Float64()*2 - 1) * amount * 255
+				return color.NRGBA{R: clamp(float64(pixel.R) + noise), G: clamp(float64(pixel.G) + noise), B: clamp(float64(pixel.B) + noise), A: pixel.A}
+			}), nil
+		}, nil
+	case "pixelate":
+		size, sizeErr := getNumber(arguments, 0, 8)
+		if sizeErr != nil || size < 1 {
+			return nil, errors.New("size must be a number of pixels")
+		}
+		return func(img image.Image) (image.Image, error) {
+			return pixelate(img, int(size)), nil
+		}, nil
+	}
+	return nil, errors.New("unknown effect " + name + ", must be blur, darken, brighten, desaturate, duotone, tint, vignette, grain or pixelate")
+}
+
+func getNumber(arguments []string, index int, defaultValue float64) (float64, error) {
+	if index >= len(arguments) || arguments[index] == "" {
+		return defaultValue, nil
+	}
+	return strconv.ParseFloat(arguments[index], 64)
+}
+
+func getAmount(arguments []string, index int, defaultValue float64) (float64, error) {
+	amount, amountErr := getNumber(arguments, index, defaultValue)
+	if amountErr != nil || amount < 0 || amount > 1 {
+		return 0, errors.New("amount must be a number from 0 to 1")
+	}
+	return amount, nil
+}
+
+// Empty is true for a chain without effects, which leaves images as they are.
+func (chain Chain) Empty() bool {
+	return len(chain.effects) == 0
+}
+
+// Hash identifies the chain in cache file names. Chains only written with other case or spacing share it.
+func (chain Chain) Hash() string {
+	hash := sha1.Sum([]byte(chain.spec))
+	return hex.EncodeToString(hash[:8])
+}
+
+// Apply runs the effects of the chain on the image one after the other.
+func (chain Chain) Apply(img image.Image) (image.Image, error) {
+	for _, chainEffect := range chain.effects {
+		var effectErr error
+		img, effectErr = chainEffect(img)
+		if effectErr != nil {
+			return nil, effectErr
+		}
+	}
+	return img, nil
+}
+
+func mapPixels(img image.Image, change func(pixel color.NRGBA) color.NRGBA) *image.NRGBA {
+	changed := toNRGBA(img)
+	for offset := 0; offset+3 < len(changed.Pix); offset += 4 {
+		pixel := change(color.NRGBA{R: changed.Pix[offset], G: changed.Pix[offset+1], B: changed.Pix[offset+2], A: changed.Pix[offset+3]})
+		changed.Pix[offset], changed.Pix[offset+1], changed.Pix[offset+2], changed.Pix[offset+3] = pixel.R, pixel.G, pixel.B, pixel.A
+	}
+	return changed
+}
+
+// vignette darkens the image towards its corners, the middle keeps its brightness.
+func vignette(img image.Image, strength float64) *image.NRGBA {
+	changed := toNRGBA(img)
+	bounds := changed.Bounds()
+	centerX, centerY := float64(bounds.Dx())/2, float64(bounds.Dy())/2
+	maxDistance := centerX*centerX + centerY*centerY
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			deltaX, deltaY := float64(x)-centerX, float64(y)-centerY
+			factor := 1 - strength*(deltaX*deltaX+deltaY*deltaY)/maxDistance
+			offset := y*changed.Stride + x*4
+			for channel := 0; channel < 3; channel++ {
+				changed.Pix[offset+channel] = clamp(float64(changed.Pix[offset+channel]) * factor)
+			}
+		}
+	}
+	return changed
+}
+
+// pixelate replaces each block of size by size pixels by its average colour.
+func pixelate(img image.Image, size int) *image.NRGBA {
+	changed := toNRGBA(img)
+	bounds := changed.Bounds()
+	for blockY := 0; blockY < bounds.Dy(); blockY += size {
+		for blockX := 0; blockX < bounds.Dx(); blockX += size {
+			maxX, maxY := minInt(blockX+size, bounds.Dx()), minInt(blockY+size, bounds.Dy())
+			var sums [4]float64
+			for y := blockY; y < maxY; y++ {
+				for x := blockX; x < maxX; x++ {
+					offset := y*changed.Stride + x*4
+					for channel := 0; channel < 4; channel++ {
+						sums[channel] += float64(changed.Pix[offset+channel])
+					}
+				}
+			}
+			count := float64((maxX - blockX) * (maxY - blockY))
+			for y := blockY; y < maxY; y++ {
+				for x := blockX; x < maxX; x++ {
+					offset := y*changed.Stride + x*4
+					for channel := 0; channel < 4; channel++ {
+						changed.Pix[offset+channel] = clamp(sums[channel] / count)
+					}
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// toNRGBA copies the image into a new image starting at 0,0 the effects can change.
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	copied := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(copied, copied.Bounds(), img, bounds.Min, draw.Src)
+	return copied
+}
+
+func brightness(pixel color.NRGBA) float64 {
+	return 0.299*float64(pixel.R) + 0.587*float64(pixel.G) + 0.114*float64(pixel.B)
+}
+
+// mix moves the colour the share of the way to the target, alpha included.
+func mix(colour color.NRGBA, target color.NRGBA, share float64) color.NRGBA {
+	move := func(from uint8, to uint8) uint8 {
+		return clamp(float64(from) + (float64(to)-float64(from))*share)
+	}
+	return color.NRGBA{R: move(colour.R, target.R), G: move(colour.G, target.G), B: move(colour.B, target.B), A: move(colour.A, target.A)}
+}
+
+func clamp(value float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, value+0.5)))
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
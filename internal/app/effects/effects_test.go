@@ -0,0 +1,135 @@
+package effects
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// newTwoTone returns a square image, red on the left half and blue on the right.
+func newTwoTone(size int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			pixel := color.NRGBA{R: 200, G: 40, B: 40, A: 255}
+			if x >= size/2 {
+				pixel = color.NRGBA{R: 40, G: 40, B: 200, A: 255}
+			}
+			img.SetNRGBA(x, y, pixel)
+		}
+	}
+	return img
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec  string
+		valid bool
+		count int
+	}{
+		{"", true, 0},
+		{"blur", true, 1},
+		{" Blur:1 , DARKEN:0.3,", true, 2},
+		{"blur:254,desaturate,duotone:#1a1a40:#f0c080,tint:#ff8800,vignette,grain:0,pixelate:2", true, 7},
+		{"blur:0", false, 0},
+		{"blur:0.5", false, 0},
+		{"blur:255", false, 0},
+		{"blur:-3", false, 0},
+		{"blur:wide", false, 0},
+		{"darken:1.5", false, 0},
+		{"brighten:-0.1", false, 0},
+		{"duotone:#1a1a40", false, 0},
+		{"duotone:#1a1a40:navy", false, 0},
+		{"tint", false, 0},
+		{"tint:#ff8800:2", false, 0},
+		{"pixelate:0", false, 0},
+		{"sharpen", false, 0},
+		{"blur:20,sepia", false, 0},
+	}
+	for _, test := range tests {
+		chain, err := Parse(test.spec)
+		if (err == nil) != test.valid {
+			t.Errorf("Parse(%q) error = %v, want valid %v", test.spec, err, test.valid)
+			continue
+		}
+		if len(chain.effects) != test.count {
+			t.Errorf("Parse(%q) = %d effects, want %d", test.spec, len(chain.effects), test.count)
+		}
+	}
+}
+
+func TestChainHash(t *testing.T) {
+	chain, _ := Parse("blur:12,desaturate")
+	respaced, _ := Parse(" BLUR:12 ,Desaturate ")
+	other, _ := Parse("blur:13,desaturate")
+	if chain.Hash() != respaced.Hash() || chain.Hash() == other.Hash() {
+		t.Errorf("Hash = %s, respaced %s and other %s, want only the respaced chain to share it", chain.Hash(),
+			respaced.Hash(), other.Hash())
+	}
+}
+
+func TestEffects(t *testing.T) {
+	red := color.NRGBA{R: 200, G: 40, B: 40, A: 255}
+	tests := []struct {
+		spec  string
+		check func(img *image.NRGBA) bool
+	}{
+		{"blur:4", func(img *image.NRGBA) bool {
+			// The edge between the halves gets a colour in between, the far sides keep theirs
+			edge := img.NRGBAAt(16, 16)
+			return edge.R > 40 && edge.R < 200 && edge.B > 40 && edge.B < 200 && img.NRGBAAt(0, 16).R > 180
+		}},
+		{"blur:254", func(img *image.NRGBA) bool { return img.Bounds().Dx() == 32 }},
+		{"darken:1", func(img *image.NRGBA) bool { return img.NRGBAAt(0, 0) == color.NRGBA{A: 255} }},
+		{"brighten:0.5", func(img *image.NRGBA) bool { return img.NRGBAAt(0, 0) == color.NRGBA{R: 228, G: 148, B: 148, A: 255} }},
+		{"desaturate", func(img *image.NRGBA) bool {
+			pixel := img.NRGBAAt(0, 0)
+			return pixel.R == pixel.G && pixel.G == pixel.B
+		}},
+		{"duotone:#000000:#ffffff", func(img *image.NRGBA) bool {
+			pixel := img.NRGBAAt(0, 0)
+			return pixel.R == pixel.G && pixel.G == pixel.B && pixel.R == uint8(brightness(red)+0.5)
+		}},
+		{"tint:#ffffff:0", func(img *image.NRGBA) bool { return img.NRGBAAt(0, 0) == red }},
+		{"vignette:1", func(img *image.NRGBA) bool {
+			return img.NRGBAAt(0, 0).R < 20 && img.NRGBAAt(8, 16).R > 150
+		}},
+		{"grain:0.2", func(img *image.NRGBA) bool {
+			differs := false
+			for x := 0; x < 16; x++ {
+				differs = differs || img.NRGBAAt(x, 0) != red
+			}
+			return differs
+		}},
+		{"pixelate:32", func(img *image.NRGBA) bool {
+			return img.NRGBAAt(0, 0) == img.NRGBAAt(31, 31) && img.NRGBAAt(0, 0) == color.NRGBA{R: 120, G: 40, B: 120, A: 255}
+		}},
+	}
+	for _, test := range tests {
+		chain, parseErr := Parse(test.spec)
+		if parseErr != nil {
+			t.Fatalf("Parse(%q) failed: %v", test.spec, parseErr)
+		}
+		original := newTwoTone(32)
+		result, applyErr := chain.Apply(original)
+		if applyErr != nil {
+			t.Errorf("%s failed: %v", test.spec, applyErr)
+			continue
+		}
+		if !test.check(toNRGBA(result)) {
+			t.Errorf("%s gave an unexpected image, e.g. %v at 0,0 and %v at 16,16", test.spec,
+				toNRGBA(result).NRGBAAt(0, 0), toNRGBA(result).NRGBAAt(16, 16))
+		}
+		if original.NRGBAAt(0, 0) != red {
+			t.Errorf("%s changed the image given, which is shared with the image cache", test.spec)
+		}
+	}
+}
+
+func TestEmptyChainKeepsTheImage(t *testing.T) {
+	chain, _ := Parse("")
+	original := newTwoTone(4)
+	if result, err := chain.Apply(original); err != nil || result != image.Image(original) || !chain.Empty() {
+		t.Errorf("Apply of the empty chain = %v, %v, want the image itself", result, err)
+	}
+}
@@ -0,0 +1,159 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"os"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// apngWriter writes an animated PNG. The standard library has no APNG encoder, so each frame is encoded as a PNG and
+// its image data is moved into the APNG frame chunks. The frames are kept compressed until the file is written on
+// close, the animation chunk in front of them needs the frame count.
+type apngWriter struct {
+	output    string
+	frameRate int
+	header    []byte
+	frames    []apngFrame
+}
+
+type apngFrame struct {
+	width     int
+	height    int
+	count     int
+	imageData [][]byte
+}
+
+func newApngWriter(output string, frameRate int) *apngWriter {
+	return &apngWriter{output: output, frameRate: frameRate}
+}
+
+func (writer *apngWriter) WriteFrame(frame image.Image, count int) error {
+	var encoded bytes.Buffer
+	encodeErr := png.Encode(&encoded, frame)
+	if encodeErr != nil {
+		return encodeErr
+	}
+	chunks, readErr := readChunks(encoded.Bytes())
+	if readErr != nil {
+		return readErr
+	}
+	apngFrame := apngFrame{width: frame.Bounds().Dx(), height: frame.Bounds().Dy(), count: count}
+	for _, chunk := range chunks {
+		switch chunk.kind {
+		case "IHDR":
+			// The frames share the header of the first frame, the PNG encoder picks the colour type by content
+			if writer.header == nil {
+				writer.header = chunk.data
+			} else if !bytes.Equal(writer.header[8:], chunk.data[8:]) {
+				return errors.New("Frame colour type differs from the first frame")
+			}
+		case "IDAT":
+			apngFrame.imageData = append(apngFrame.imageData, chunk.data)
+		}
+	}
+	writer.frames = append(writer.frames, apngFrame)
+	return nil
+}
+
+func (writer *apngWriter) Close() error {
+	if len(writer.frames) == 0 {
+		return errors.New("No frames to export")
+	}
+	apngFile, createErr := os.Create(writer.output)
+	if createErr != nil {
+		return createErr
+	}
+	writeErr := writer.writeTo(apngFile)
+	closeErr := apngFile.Close()
+	if writeErr != nil {
+		return writeErr
+	}
+	return closeErr
+}
+
+func (writer *apngWriter) writeTo(output io.Writer) error {
+	var animation bytes.Buffer
+	animation.Write(pngSignature)
+	writeChunk(&animation, "IHDR", writer.header)
+	// Frame count and 0 for endless looping
+	writeChunk(&animation, "acTL", uint32s(uint32(len(writer.frames)), 0))
+	sequenceNumber := uint32(0)
+	for frameIndex, frame := range writer.frames {
+		// Frame control: size, offset, delay as count over frame rate, no disposal and no blending
+		frameControl := append(uint32s(sequenceNumber, uint32(frame.width), uint32(frame.height), 0, 0),
+			uint16s(uint16(frame.count), uint16(writer.frameRate))...)
+		writeChunk(&animation, "fcTL", append(frameControl, 0, 0))
+		sequenceNumber++
+		for _, data := range frame.imageData {
+			// The first frame is the default image shown by viewers without APNG support
+			if frameIndex == 0 {
+				writeChunk(&animation, "IDAT", data)
+				continue
+			}
+			writeChunk(&animation, "fdAT", append(uint32s(sequenceNumber), data...))
+			sequenceNumber++
+		}
+		// Writing as the frames go keeps the animation buffer small
+		if _, flushErr := animation.WriteTo(output); flushErr != nil {
+			return flushErr
+		}
+	}
+	writeChunk(&animation, "IEND", nil)
+	_, flushErr := animation.WriteTo(output)
+	return flushErr
+}
+
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+func readChunks(encoded []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(encoded, pngSignature) {
+		return nil, errors.New("Encoded frame is no PNG")
+	}
+	var chunks []pngChunk
+	for position := len(pngSignature); position+8 <= len(encoded); {
+		length := int(binary.BigEndian.Uint32(encoded[position:]))
+		dataStart := position + 8
+		if dataStart+length+4 > len(encoded) {
+			return nil, errors.New("Encoded frame is truncated")
+		}
+		chunks = append(chunks, pngChunk{kind: string(encoded[position+4 : dataStart]), data: encoded[dataStart : dataStart+length]})
+		position = dataStart + length + 4
+	}
+	return chunks, nil
+}
+
+func writeChunk(output *bytes.Buffer, kind string, data []byte) {
+	output.Write(uint32s(uint32(len(data))))
+	checksum := crc32.NewIEEE()
+	checksum.Write([]byte(kind))
+	checksum.Write(data)
+	output.WriteString(kind)
+	output.Write(data)
+	output.Write(uint32s(checksum.Sum32()))
+}
+
+func uint32s(values ...uint32) []byte {
+	encoded := make([]byte, 4*len(values))
+	for index, value := range values {
+		binary.BigEndian.PutUint32(encoded[4*index:], value)
+	}
+	return encoded
+}
+
+func uint16s(values ...uint16) []byte {
+	encoded := make([]byte, 2*len(values))
+	for index, value := range values {
+		binary.BigEndian.PutUint16(encoded[2*index:], value)
+	}
+	return encoded
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"errors"
+	"github.com/magiconair/properties"
+	"image"
+	"image/draw"
+	"strings"
+)
+
+// Config is the timing of an exported slideshow.
+type Config struct {
+	FrameRate         int
+	GameSeconds       float64
+	Transition        string
+	TransitionSeconds float64
+}
+
+func LoadConfig(props properties.Properties) Config {
+	return Config{
+		FrameRate:         props.GetInt("visualizer.export.fps", 10),
+		GameSeconds:       props.GetFloat64("visualizer.export.game.seconds", 3),
+		Transition:        strings.ToLower(props.GetString("visualizer.export.transition", "fade")),
+		TransitionSeconds: props.GetFloat64("visualizer.export.transition.seconds", 1),
+	}
+}
+
+// Writer writes the frames of an exported slideshow. A frame shown for several frame times in a row is written once
+// with its count, so formats with frame delays do not store it again.
+type Writer interface {
+	WriteFrame(frame image.Image, count int) error
+	Close() error
+}
+
+// NewWriter returns the writer of the format: gif, apng or png for a numbered PNG frame sequence in the output
+// directory.
+func NewWriter(format string, output string, frameRate int) (Writer, error) {
+	switch strings.ToLower(format) {
+	case "gif":
+		return newGifWriter(output, frameRate), nil
+	case "apng":
+		return newApngWriter(output, frameRate), nil
+	case "png":
+		return newPngSequenceWriter(output)
+	}
+	return nil, errors.New("Unknown export format " + format + ", must be gif, apng or png")
+}
+
+// Write writes the slides to the writer, each slide shown for the game time followed by the transition to the next
+// slide. All slides must be of the same size.
+func Write(slides []image.Image, config Config, writer Writer) error {
+	if config.FrameRate < 1 {
+		return errors.New("The export frame rate must be at least 1")
+	}
+	transitionFrames := int(config.TransitionSeconds * float64(config.FrameRate))
+	if config.Transition == "none" {
+		transitionFrames = 0
+	}
+	holdFrames := int(config.GameSeconds*float64(config.FrameRate)) - transitionFrames
+	if holdFrames < 1 {
+		holdFrames = 1
+	}
+
+	for slideIndex, slide := range slides {
+		writeErr := writer.WriteFrame(slide, holdFrames)
+		if writeErr != nil {
+			return writeErr
+		}
+		// The last slide has no transition, a looping animation jumps back to the first slide
+		if slideIndex == len(slides)-1 {
+			break
+		}
+		for frame := 1; frame <= transitionFrames; frame++ {
+			progress := float64(frame) / float64(transitionFrames+1)
+			transitionErr := writer.WriteFrame(transition(config.Transition, slide, slides[slideIndex+1], progress), 1)
+			if transitionErr != nil {
+				return transitionErr
+			}
+		}
+	}
+	return nil
+}
+
+// transition is the frame at the progress between 0 and 1 of the transition from one slide to the next. A fade blends
+// the slides, a slide pushes the next slide in from the right.
+func transition(kind string, from image.Image, to image.Image, progress float64) image.Image {
+	bounds := from.Bounds()
+	frame := image.NewRGBA(bounds)
+	if kind == "slide" {
+		offset := int(float64(bounds.Dx()) * progress)
+		draw.Draw(frame, bounds, from, bounds.Min.Add(image.Pt(offset, 0)), draw.Src)
+		draw.Draw(frame, image.Rect(bounds.Max.X-offset, bounds.Min.Y, bounds.Max.X, bounds.Max.Y), to, to.Bounds().Min, draw.Src)
+		return frame
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			fromR, fromG, fromB, _ := from.At(x, y).RGBA()
+			toR, toG, toB, _ := to.At(x, y).RGBA()
+			offset := frame.PixOffset(x, y)
+			frame.Pix[offset] = blend(fromR, toR, progress)
+			frame.Pix[offset+1] = blend(fromG, toG, progress)
+			frame.Pix[offset+2] = blend(fromB, toB, progress)
+			frame.Pix[offset+3] = 0xff
+		}
+	}
+	return frame
+}
+
+func blend(from uint32, to uint32, progress float64) uint8 {
+	return uint8((float64(from)*(1-progress) + float64(to)*progress) / 0x101)
+}
@@ -0,0 +1,200 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"path/filepath"
+	"testing"
+)
+
+func newUniformFrame(colour color.Color) image.Image {
+	frame := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(colour), image.Point{}, draw.Src)
+	return frame
+}
+
+// newQuadrantFrame has a red, green, blue and white quarter.
+func newQuadrantFrame() image.Image {
+	frame := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	quadrants := []color.RGBA{{R: 255, A: 255}, {G: 255, A: 255}, {B: 255, A: 255}, {R: 255, G: 255, B: 255, A: 255}}
+	for index, colour := range quadrants {
+		origin := image.Pt(index%2*4, index/2*4)
+		draw.Draw(frame, image.Rectangle{Min: origin, Max: origin.Add(image.Pt(4, 4))}, image.NewUniform(colour), image.Point{}, draw.Src)
+	}
+	return frame
+}
+
+func TestApngRoundTrip(t *testing.T) {
+	writer := newApngWriter("", 10)
+	frames := []struct {
+		colour color.RGBA
+		count  int
+	}{
+		{color.RGBA{R: 200, G: 10, B: 10, A: 255}, 30},
+		{color.RGBA{R: 10, G: 200, B: 10, A: 255}, 1},
+		{color.RGBA{R: 10, G: 10, B: 200, A: 255}, 30},
+	}
+	for _, frame := range frames {
+		if err := writer.WriteFrame(newUniformFrame(frame.colour), frame.count); err != nil {
+			t.Fatalf("WriteFrame failed: %v", err)
+		}
+	}
+	var animation bytes.Buffer
+	if err := writer.writeTo(&animation); err != nil {
+		t.Fatalf("writeTo failed: %v", err)
+	}
+
+	// Viewers without APNG support show the default image, the first frame
+	defaultImage, decodeErr := png.Decode(bytes.NewReader(animation.Bytes()))
+	if decodeErr != nil {
+		t.Fatalf("Decoding the default image failed: %v", decodeErr)
+	}
+	if got := color.RGBAModel.Convert(defaultImage.At(3, 3)); got != frames[0].colour {
+		t.Errorf("default image colour = %v, want %v", got, frames[0].colour)
+	}
+
+	encoded := animation.Bytes()[len(pngSignature):]
+	var kinds []string
+	var frameCount, frameControls uint32
+	var delays []uint16
+	var sequenceNumbers []uint32
+	for len(encoded) >= 12 {
+		length := binary.BigEndian.Uint32(encoded)
+		kind, data := string(encoded[4:8]), encoded[8:8+length]
+		if checksum := binary.BigEndian.Uint32(encoded[8+length:]); checksum != crc32.ChecksumIEEE(encoded[4:8+length]) {
+			t.Errorf("%s chunk CRC = %08x, want %08x", kind, checksum, crc32.ChecksumIEEE(encoded[4:8+length]))
+		}
+		switch kind {
+		case "acTL":
+			frameCount = binary.BigEndian.Uint32(data)
+		case "fcTL":
+			frameControls++
+			sequenceNumbers = append(sequenceNumbers, binary.BigEndian.Uint32(data))
+			delays = append(delays, binary.BigEndian.Uint16(data[20:]))
+		case "fdAT":
+			sequenceNumbers = append(sequenceNumbers, binary.BigEndian.Uint32(data))
+		}
+		kinds = append(kinds, kind)
+		encoded = encoded[12+length:]
+	}
+	if len(encoded) != 0 {
+		t.Errorf("%d bytes left after the last chunk", len(encoded))
+	}
+	if kinds[0] != "IHDR" || kinds[1] != "acTL" || kinds[len(kinds)-1] != "IEND" {
+		t.Errorf("chunks = %v, want IHDR and acTL first and IEND last", kinds)
+	}
+	if frameCount != uint32(len(frames)) || frameControls != uint32(len(frames)) {
+		t.Errorf("acTL frame count = %d and %d fcTL chunks, want %d", frameCount, frameControls, len(frames))
+	}
+	for index, frame := range frames {
+		if delays[index] != uint16(frame.count) {
+			t.Errorf("frame %d delay = %d, want %d", index, delays[index], frame.count)
+		}
+	}
+	for index, sequenceNumber := range sequenceNumbers {
+		if sequenceNumber != uint32(index) {
+			t.Errorf("sequence numbers = %v, want 0 counting up", sequenceNumbers)
+			break
+		}
+	}
+}
+
+func TestGifDelay(t *testing.T) {
+	tests := []struct {
+		frameRate int
+		count     int
+		delay     int
+	}{
+		{10, 1, 10},
+		{10, 30, 300},
+		{50, 1, 2},
+		{120, 1, 2},
+		{120, 3, 3},
+		{30, 1, 3},
+		{1000, 1, 2},
+	}
+	for _, test := range tests {
+		writer := newGifWriter("", test.frameRate)
+		if err := writer.WriteFrame(newUniformFrame(color.White), test.count); err != nil {
+			t.Fatalf("WriteFrame failed: %v", err)
+		}
+		if got := writer.animation.Delay[0]; got != test.delay {
+			t.Errorf("delay of %d frames at %d fps = %d, want %d", test.count, test.frameRate, got, test.delay)
+		}
+	}
+}
+
+func TestGifDelaysKeepTheFrameRate(t *testing.T) {
+	tests := []struct {
+		frameRate int
+		frames    int
+		total     int
+	}{
+		{30, 30, 100},
+		{30, 45, 150},
+		{24, 240, 1000},
+		{7, 7, 100},
+		// Capped at 50 fps
+		{120, 120, 240},
+	}
+	for _, test := range tests {
+		writer := newGifWriter("", test.frameRate)
+		frame := newUniformFrame(color.White)
+		for index := 0; index < test.frames; index++ {
+			if err := writer.WriteFrame(frame, 1); err != nil {
+				t.Fatalf("WriteFrame failed: %v", err)
+			}
+		}
+		total := 0
+		for index, delay := range writer.animation.Delay {
+			if delay < 2 {
+				t.Errorf("frame %d at %d fps delay = %d, want at least 2", index, test.frameRate, delay)
+			}
+			total += delay
+		}
+		if total != test.total {
+			t.Errorf("%d frames at %d fps take %d hundredths, want %d", test.frames, test.frameRate, total, test.total)
+		}
+	}
+}
+
+func TestCloseWithoutFrames(t *testing.T) {
+	directory := t.TempDir()
+	for _, format := range []string{"gif", "apng", "png"} {
+		writer, writerErr := NewWriter(format, filepath.Join(directory, format), 10)
+		if writerErr != nil {
+			t.Fatalf("NewWriter(%s) failed: %v", format, writerErr)
+		}
+		if err := Write(nil, Config{FrameRate: 10, GameSeconds: 3}, writer); err != nil {
+			t.Fatalf("Write of no slides failed: %v", err)
+		}
+		if err := writer.Close(); err == nil {
+			t.Errorf("%s writer closed without frames succeeded, want an error", format)
+		}
+	}
+}
+
+func TestMedianCut(t *testing.T) {
+	quadrants := newQuadrantFrame()
+	palette := MedianCut(quadrants, 4)
+	if len(palette) != 4 {
+		t.Fatalf("MedianCut of 4 colours = %d colours, want 4", len(palette))
+	}
+	for _, point := range []image.Point{{0, 0}, {4, 0}, {0, 4}, {4, 4}} {
+		want := quadrants.At(point.X, point.Y)
+		if got := palette.Convert(want); got != want {
+			t.Errorf("palette colour nearest to %v = %v, want the colour itself", want, got)
+		}
+	}
+	if got := len(MedianCut(quadrants, 2)); got != 2 {
+		t.Errorf("MedianCut of 4 colours to 2 = %d colours, want 2", got)
+	}
+	if got := len(MedianCut(newUniformFrame(color.White), 256)); got != 1 {
+		t.Errorf("MedianCut of a single colour = %d colours, want 1", got)
+	}
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"os"
+)
+
+// gifWriter quantises each frame to its own 256 colour palette with Floyd-Steinberg dithering. GIF keeps all frames
+// until the file is encoded on close.
+type gifWriter struct {
+	output    string
+	frameRate int
+	animation gif.GIF
+	// frames counts the frames written and elapsed the hundredths of a second their delays add up to
+	frames  int
+	elapsed int
+}
+
+func newGifWriter(output string, frameRate int) *gifWriter {
+	return &gifWriter{output: output, frameRate: frameRate}
+}
+
+func (writer *gifWriter) WriteFrame(frame image.Image, count int) error {
+	palettedFrame := image.NewPaletted(frame.Bounds(), MedianCut(frame, 256))
+	draw.FloydSteinberg.Draw(palettedFrame, frame.Bounds(), frame, frame.Bounds().Min)
+	writer.animation.Image = append(writer.animation.Image, palettedFrame)
+	// GIF delays are in hundredths of a second. Each delay ends the frame at the rounded time of the frames written so
+	// far, so the rounding errors do not add up, e.g. 30 fps alternates 3 and 4. Browsers play delays below 2 slowly, as
+	// if they were 10, so frame rates above 50 are capped at 50
+	writer.frames += count
+	delay := (writer.frames*100+writer.frameRate/2)/writer.frameRate - writer.elapsed
+	if delay < 2 {
+		delay = 2
+	}
+	writer.elapsed += delay
+	writer.animation.Delay = append(writer.animation.Delay, delay)
+	return nil
+}
+
+func (writer *gifWriter) Close() error {
+	if len(writer.animation.Image) == 0 {
+		return errors.New("No frames to export")
+	}
+	gifFile, createErr := os.Create(writer.output)
+	if createErr != nil {
+		return createErr
+	}
+	encodeErr := gif.EncodeAll(gifFile, &writer.animation)
+	closeErr := gifFile.Close()
+	if encodeErr != nil {
+		return encodeErr
+	}
+	return closeErr
+}
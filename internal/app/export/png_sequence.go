@@ -0,0 +1,51 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// pngSequenceWriter writes every frame time as a numbered PNG file, the constant frame rate input ffmpeg expects, e.g.
+//
+//	ffmpeg -framerate 10 -i frame-%05d.png highlights.mp4
+type pngSequenceWriter struct {
+	directory   string
+	frameNumber int
+}
+
+func newPngSequenceWriter(directory string) (*pngSequenceWriter, error) {
+	mkdirErr := os.MkdirAll(directory, 0755)
+	if mkdirErr != nil {
+		return nil, mkdirErr
+	}
+	return &pngSequenceWriter{directory: directory}, nil
+}
+
+func (writer *pngSequenceWriter) WriteFrame(frame image.Image, count int) error {
+	var encoded bytes.Buffer
+	encodeErr := png.Encode(&encoded, frame)
+	if encodeErr != nil {
+		return encodeErr
+	}
+	// A frame shown for several frame times is encoded once and written as many times
+	for i := 0; i < count; i++ {
+		writer.frameNumber++
+		writeErr := os.WriteFile(filepath.Join(writer.directory, fmt.Sprintf("frame-%05d.png", writer.frameNumber)), encoded.Bytes(), 0644)
+		if writeErr != nil {
+			return writeErr
+		}
+	}
+	return nil
+}
+
+func (writer *pngSequenceWriter) Close() error {
+	if writer.frameNumber == 0 {
+		return errors.New("No frames to export")
+	}
+	return nil
+}
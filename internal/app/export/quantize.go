@@ -0,0 +1,107 @@
+package export
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// maxSamples caps the pixels the palette is computed from, a sample of a large frame gives the same colours.
+const maxSamples = 1 << 16
+
+type colorBox struct {
+	pixels []color.RGBA
+}
+
+// MedianCut computes a palette of at most maxColors colours for the image. The pixels are split in boxes, the box with
+// the widest channel range is cut in two at the median of that channel until there are enough boxes, and each box
+// gives the average colour of its pixels.
+func MedianCut(img image.Image, maxColors int) color.Palette {
+	bounds := img.Bounds()
+	step := 1
+	for (bounds.Dx()/step)*(bounds.Dy()/step) > maxSamples {
+		step++
+	}
+	var pixels []color.RGBA
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			pixels = append(pixels, color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff})
+		}
+	}
+
+	boxes := []colorBox{{pixels: pixels}}
+	for len(boxes) < maxColors {
+		boxIndex, channel, widestRange := -1, 0, 0
+		for index, box := range boxes {
+			if len(box.pixels) < 2 {
+				continue
+			}
+			boxChannel, boxRange := box.widestChannel()
+			if boxRange > widestRange {
+				boxIndex, channel, widestRange = index, boxChannel, boxRange
+			}
+		}
+		// Every box holds a single colour
+		if boxIndex < 0 {
+			break
+		}
+		box := boxes[boxIndex]
+		sort.Slice(box.pixels, func(i, j int) bool {
+			return getChannel(box.pixels[i], channel) < getChannel(box.pixels[j], channel)
+		})
+		median := len(box.pixels) / 2
+		boxes[boxIndex] = colorBox{pixels: box.pixels[:median]}
+		boxes = append(boxes, colorBox{pixels: box.pixels[median:]})
+	}
+
+	palette := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		palette = append(palette, box.average())
+	}
+	return palette
+}
+
+func (box colorBox) widestChannel() (int, int) {
+	channel, widestRange := 0, 0
+	for candidate := 0; candidate < 3; candidate++ {
+		minimum, maximum := 255, 0
+		for _, pixel := range box.pixels {
+			value := getChannel(pixel, candidate)
+			if value < minimum {
+				minimum = value
+			}
+			if value > maximum {
+				maximum = value
+			}
+		}
+		if maximum-minimum > widestRange {
+			channel, widestRange = candidate, maximum-minimum
+		}
+	}
+	return channel, widestRange
+}
+
+func (box colorBox) average() color.RGBA {
+	if len(box.pixels) == 0 {
+		return color.RGBA{A: 0xff}
+	}
+	var r, g, b int
+	for _, pixel := range box.pixels {
+		r += int(pixel.R)
+		g += int(pixel.G)
+		b += int(pixel.B)
+	}
+	count := len(box.pixels)
+	return color.RGBA{R: uint8(r / count), G: uint8(g / count), B: uint8(b / count), A: 0xff}
+}
+
+func getChannel(pixel color.RGBA, channel int) int {
+	switch channel {
+	case 0:
+		return int(pixel.R)
+	case 1:
+		return int(pixel.G)
+	}
+	return int(pixel.B)
+}
@@ -0,0 +1,224 @@
+package filter
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+// Filter decides if a game is part of the library shown by the visualizer.
+type Filter interface {
+	Match(game domain.ClientGame) bool
+}
+
+// Parse compiles a filter expression such as
+//
+//	source=Steam and playtime=0 and not developer~"Valve"
+//
+// Comparisons are a field, an operator and a value. = and != compare text case-insensitively, ~ and !~ check if the
+// text is contained, and <, <=, >, >= compare numbers. A field on its own is true when it has the value true, e.g.
+// favourite. Comparisons are combined with and, or, not and parentheses. An empty expression matches every game.
+func Parse(expression string) (Filter, error) {
+	tokens, tokenizeErr := tokenize(expression)
+	if tokenizeErr != nil {
+		return nil, tokenizeErr
+	}
+	if len(tokens) == 0 {
+		return matchAll{}, nil
+	}
+	expressionParser := &parser{tokens: tokens}
+	filter, parseErr := expressionParser.parseOr()
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if expressionParser.position < len(tokens) {
+		return nil, errors.New("Unexpected " + tokens[expressionParser.position].text + " in filter expression")
+	}
+	return filter, nil
+}
+
+// Apply returns the games matched by the filter.
+func Apply(games []domain.ClientGame, filter Filter) []domain.ClientGame {
+	filteredGames := make([]domain.ClientGame, 0)
+	for _, game := range games {
+		if filter.Match(game) {
+			filteredGames = append(filteredGames, game)
+		}
+	}
+	return filteredGames
+}
+
+type matchAll struct{}
+
+func (matchAll) Match(_ domain.ClientGame) bool {
+	return true
+}
+
+type and struct {
+	left, right Filter
+}
+
+func (filter and) Match(game domain.ClientGame) bool {
+	return filter.left.Match(game) && filter.right.Match(game)
+}
+
+type or struct {
+	left, right Filter
+}
+
+func (filter or) Match(game domain.ClientGame) bool {
+	return filter.left.Match(game) || filter.right.Match(game)
+}
+
+type not struct {
+	filter Filter
+}
+
+func (filter not) Match(game domain.ClientGame) bool {
+	return !filter.filter.Match(game)
+}
+
+// comparison matches when any of the field values compares true, so developer="Valve" matches a game with several
+// developers of which one is Valve. The negated operators are parsed as not comparisons so developer!="Valve" matches
+// only when none of the developers is Valve.
+type comparison struct {
+	field    func(game domain.ClientGame) []string
+	operator string
+	value    string
+}
+
+func (filter comparison) Match(game domain.ClientGame) bool {
+	for _, fieldValue := range filter.field(game) {
+		if compare(fieldValue, filter.operator, filter.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(fieldValue string, operator string, value string) bool {
+	switch operator {
+	case "=":
+		return strings.EqualFold(fieldValue, value)
+	case "~":
+		return strings.Contains(strings.ToUpper(fieldValue), strings.ToUpper(value))
+	}
+	fieldNumber, fieldErr := strconv.ParseFloat(fieldValue, 64)
+	valueNumber, valueErr := strconv.ParseFloat(value, 64)
+	if fieldErr != nil || valueErr != nil {
+		return false
+	}
+	switch operator {
+	case "<":
+		return fieldNumber < valueNumber
+	case "<=":
+		return fieldNumber <= valueNumber
+	case ">":
+		return fieldNumber > valueNumber
+	case ">=":
+		return fieldNumber >= valueNumber
+	}
+	return false
+}
+
+var fields = map[string]func(game domain.ClientGame) []string{
+	"name": func(game domain.ClientGame) []string {
+		return []string{game.Name}
+	},
+	"source": func(game domain.ClientGame) []string {
+		return []string{game.Source.String()}
+	},
+	"source-id": func(game domain.ClientGame) []string {
+		return []string{game.SourceId}
+	},
+	"description": func(game domain.ClientGame) []string {
+		return []string{game.Description}
+	},
+	"developer": func(game domain.ClientGame) []string {
+		return game.Developers
+	},
+	"playtime": func(game domain.ClientGame) []string {
+		return []string{strconv.Itoa(game.PlaytimeForever)}
+	},
+	"played": func(game domain.ClientGame) []string {
+		return []string{strconv.FormatBool(game.PlaytimeForever > 0)}
+	},
+	"launches": func(game domain.ClientGame) []string {
+		return []string{strconv.Itoa(game.LaunchCount)}
+	},
+	"favourite": func(game domain.ClientGame) []string {
+		return []string{strconv.FormatBool(game.Favourite)}
+	},
+	"hidden": func(game domain.ClientGame) []string {
+		return []string{strconv.FormatBool(game.Hidden)}
+	},
+	"genre": func(game domain.ClientGame) []string {
+		return game.Genres
+	},
+	"theme": func(game domain.ClientGame) []string {
+		return game.Themes
+	},
+	"mode": func(game domain.ClientGame) []string {
+		return game.GameModes
+	},
+	"year": func(game domain.ClientGame) []string {
+		if game.FirstReleaseDate.IsZero() {
+			return nil
+		}
+		return []string{strconv.Itoa(game.FirstReleaseDate.Year())}
+	},
+	"rating": func(game domain.ClientGame) []string {
+		return []string{strconv.FormatFloat(game.AggregatedRating, 'f', -1, 64)}
+	},
+	"user-rating": func(game domain.ClientGame) []string {
+		return []string{strconv.FormatFloat(game.UserRating, 'f', -1, 64)}
+	},
+	"summary": func(game domain.ClientGame) []string {
+		return []string{game.Summary}
+	},
+	"franchise": func(game domain.ClientGame) []string {
+		return []string{game.Franchise, game.Collection}
+	},
+	"company": func(game domain.ClientGame) []string {
+		var companies []string
+		for _, company := range game.InvolvedCompanies {
+			companies = append(companies, company.Name)
+		}
+		return companies
+	},
+	"publisher": func(game domain.ClientGame) []string {
+		var publishers []string
+		for _, company := range game.InvolvedCompanies {
+			if company.Publisher {
+				publishers = append(publishers, company.Name)
+			}
+		}
+		return publishers
+	},
+	// age is the highest minimum age of the game's age ratings, so age<=7 keeps the games every organization rated
+	// suitable for young children. Games without age ratings have no age and are not matched.
+	"age": func(game domain.ClientGame) []string {
+		maxAge := -1
+		for _, ageRating := range game.AgeRatings {
+			if ageRating.Age > maxAge {
+				maxAge = ageRating.Age
+			}
+		}
+		if maxAge < 0 {
+			return nil
+		}
+		return []string{strconv.Itoa(maxAge)}
+	},
+	"age-rating": func(game domain.ClientGame) []string {
+		var ageRatings []string
+		for _, ageRating := range game.AgeRatings {
+			ageRatings = append(ageRatings, ageRating.Organization+" "+ageRating.Rating)
+		}
+		return ageRatings
+	},
+}
+
+func init() {
+	fields["favorite"] = fields["favourite"]
+}
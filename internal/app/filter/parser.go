@@ -0,0 +1,175 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	wordToken tokenKind = iota
+	stringToken
+	operatorToken
+	openToken
+	closeToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+const operatorCharacters = "=!~<>"
+
+// operators are the comparisons a filter expression can use, any other run of operator characters is an error.
+var operators = map[string]bool{
+	"=": true, "!=": true, "~": true, "!~": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expression)
+	for position := 0; position < len(runes); {
+		current := runes[position]
+		switch {
+		case unicode.IsSpace(current):
+			position++
+		case current == '(':
+			tokens = append(tokens, token{kind: openToken, text: "("})
+			position++
+		case current == ')':
+			tokens = append(tokens, token{kind: closeToken, text: ")"})
+			position++
+		case current == '"':
+			end := position + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.New("Missing closing quote in filter expression")
+			}
+			tokens = append(tokens, token{kind: stringToken, text: string(runes[position+1 : end])})
+			position = end + 1
+		case strings.ContainsRune(operatorCharacters, current):
+			end := position + 1
+			for end < len(runes) && strings.ContainsRune(operatorCharacters, runes[end]) {
+				end++
+			}
+			operator := string(runes[position:end])
+			if !operators[operator] {
+				return nil, errors.New("Unknown operator " + operator + " in filter expression")
+			}
+			tokens = append(tokens, token{kind: operatorToken, text: operator})
+			position = end
+		default:
+			end := position
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("()\""+operatorCharacters, runes[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: wordToken, text: string(runes[position:end])})
+			position = end
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (parser *parser) peekKeyword(keyword string) bool {
+	return parser.position < len(parser.tokens) &&
+		parser.tokens[parser.position].kind == wordToken &&
+		strings.EqualFold(parser.tokens[parser.position].text, keyword)
+}
+
+func (parser *parser) parseOr() (Filter, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peekKeyword("or") {
+		parser.position++
+		right, rightErr := parser.parseAnd()
+		if rightErr != nil {
+			return nil, rightErr
+		}
+		left = or{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *parser) parseAnd() (Filter, error) {
+	left, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peekKeyword("and") {
+		parser.position++
+		right, rightErr := parser.parseNot()
+		if rightErr != nil {
+			return nil, rightErr
+		}
+		left = and{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *parser) parseNot() (Filter, error) {
+	if parser.peekKeyword("not") {
+		parser.position++
+		filter, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return not{filter: filter}, nil
+	}
+	return parser.parseTerm()
+}
+
+func (parser *parser) parseTerm() (Filter, error) {
+	if parser.position >= len(parser.tokens) {
+		return nil, errors.New("Unexpected end of filter expression")
+	}
+	current := parser.tokens[parser.position]
+	parser.position++
+
+	if current.kind == openToken {
+		filter, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if parser.position >= len(parser.tokens) || parser.tokens[parser.position].kind != closeToken {
+			return nil, errors.New("Missing closing parenthesis in filter expression")
+		}
+		parser.position++
+		return filter, nil
+	}
+	if current.kind != wordToken {
+		return nil, errors.New("Expected a field name but found " + current.text + " in filter expression")
+	}
+
+	field, found := fields[strings.ToLower(current.text)]
+	if !found {
+		return nil, errors.New("Unknown field " + current.text + " in filter expression")
+	}
+	// A field without comparison is a boolean field, e.g. favourite
+	if parser.position >= len(parser.tokens) || parser.tokens[parser.position].kind != operatorToken {
+		return comparison{field: field, operator: "=", value: "true"}, nil
+	}
+	operator := parser.tokens[parser.position].text
+	parser.position++
+	if parser.position >= len(parser.tokens) ||
+		(parser.tokens[parser.position].kind != wordToken && parser.tokens[parser.position].kind != stringToken) {
+		return nil, errors.New("Missing value after " + current.text + operator + " in filter expression")
+	}
+	value := parser.tokens[parser.position].text
+	parser.position++
+	if strings.HasPrefix(operator, "!") {
+		return not{filter: comparison{field: field, operator: strings.TrimPrefix(operator, "!"), value: value}}, nil
+	}
+	return comparison{field: field, operator: operator, value: value}, nil
+}
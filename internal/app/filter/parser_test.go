@@ -0,0 +1,95 @@
+package filter
+
+import (
+	"testing"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+var portal = domain.ClientGame{
+	Name:            "Portal 2",
+	Source:          domain.Steam,
+	Developers:      []string{"Valve"},
+	PlaytimeForever: 600,
+	Favourite:       true,
+	Genres:          []string{"Puzzle", "Shooter"},
+}
+
+var stardew = domain.ClientGame{
+	Name:            "Stardew Valley",
+	Source:          domain.Steam,
+	Developers:      []string{"ConcernedApe"},
+	PlaytimeForever: 0,
+	Genres:          []string{"Simulator", "Role-playing (RPG)"},
+}
+
+func TestParseMatches(t *testing.T) {
+	tests := []struct {
+		name         string
+		expression   string
+		portalMatch  bool
+		stardewMatch bool
+	}{
+		{"empty matches all", "", true, true},
+		{"boolean field", "favourite", true, false},
+		{"equals ignores case", "developer=valve", true, false},
+		{"contains", "name~valley", false, true},
+		{"quoted value with spaces", `genre="Role-playing (RPG)"`, false, true},
+		{"quoted value with operator characters", `name="a=b"`, false, false},
+		{"not equals", "developer!=Valve", false, true},
+		{"not contains", "name!~portal", false, true},
+		{"less than", "playtime<60", false, true},
+		{"less or equal", "playtime<=600", true, true},
+		{"greater than", "playtime>600", false, false},
+		{"greater or equal", "playtime>=600", true, false},
+		{"not keyword", "not favourite", false, true},
+		{"double not", "not not favourite", true, false},
+		{"and before or", "favourite or playtime=0 and developer=Valve", true, false},
+		{"parentheses before and", "(favourite or playtime=0) and developer=ConcernedApe", false, true},
+		{"not before and", "not favourite and playtime=0", false, true},
+		{"not applies to parentheses", "not (favourite or playtime=0)", false, false},
+		{"keywords ignore case", "favourite OR Developer=ConcernedApe", true, true},
+		{"any value of a list field", "genre=shooter", true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, parseErr := Parse(test.expression)
+			if parseErr != nil {
+				t.Fatalf("Parse(%q) failed: %v", test.expression, parseErr)
+			}
+			if got := filter.Match(portal); got != test.portalMatch {
+				t.Errorf("Parse(%q) matches Portal 2 = %v, want %v", test.expression, got, test.portalMatch)
+			}
+			if got := filter.Match(stardew); got != test.stardewMatch {
+				t.Errorf("Parse(%q) matches Stardew Valley = %v, want %v", test.expression, got, test.stardewMatch)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"name==x",
+		"name=~x",
+		"name<~3",
+		"name~~x",
+		"name=!x",
+		"name!x",
+		"name<>3",
+		"unknown=1",
+		`name="unterminated`,
+		"(favourite",
+		"favourite)",
+		"favourite and",
+		"not",
+		"name=",
+		"= x",
+		"favourite hidden",
+	}
+	for _, expression := range tests {
+		t.Run(expression, func(t *testing.T) {
+			if _, parseErr := Parse(expression); parseErr == nil {
+				t.Errorf("Parse(%q) succeeded, want an error", expression)
+			}
+		})
+	}
+}
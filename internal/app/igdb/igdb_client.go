@@ -10,6 +10,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"vg-cover-screen-saver-go/internal/app/artwork"
 	"vg-cover-screen-saver-go/internal/app/domain"
 )
 
@@ -34,29 +35,74 @@ type twitchTokenResponse struct {
 }
 
 type igdbGame struct {
-	Id          int    `json:"id"`
-	Artworks    []int  `json:"artworks"`
-	Screenshots []int  `json:"screenshots"`
-	Name        string `json:"name"`
+	Id                int                   `json:"id"`
+	Artworks          []int                 `json:"artworks"`
+	Screenshots       []int                 `json:"screenshots"`
+	Name              string                `json:"name"`
+	Genres            []igdbNamed           `json:"genres"`
+	Themes            []igdbNamed           `json:"themes"`
+	GameModes         []igdbNamed           `json:"game_modes"`
+	FirstReleaseDate  int64                 `json:"first_release_date"`
+	AggregatedRating  float64               `json:"aggregated_rating"`
+	Rating            float64               `json:"rating"`
+	Summary           string                `json:"summary"`
+	Storyline         string                `json:"storyline"`
+	Franchise         *igdbNamed            `json:"franchise"`
+	Collection        *igdbNamed            `json:"collection"`
+	InvolvedCompanies []igdbInvolvedCompany `json:"involved_companies"`
+	AgeRatings        []igdbAgeRating       `json:"age_ratings"`
 }
 
-func GetGameArtworks(clientGame domain.ClientGame, props properties.Properties) ([]domain.IgdbGameArtwork, error) {
+// GetGame returns the client game with the metadata and artworks of the matching IGDB game. The client game is
+// returned unchanged when IGDB has no match.
+func GetGame(clientGame domain.ClientGame, props properties.Properties) (domain.ClientGame, error) {
 	authToken, err := getAuthToken(props)
 	if err != nil {
-		return nil, err
+		return clientGame, err
 	}
 
 	game, gameError := getIgdbGame(clientGame, props, authToken)
 	if gameError != nil {
-		return nil, gameError
+		return clientGame, gameError
+	}
+	return setGame(clientGame, game, props, authToken)
+}
+
+// GetGameById returns the client game with the metadata and artworks of the IGDB game with the id, for games the name
+// search matches with the wrong IGDB game.
+func GetGameById(clientGame domain.ClientGame, igdbId int, props properties.Properties) (domain.ClientGame, error) {
+	authToken, err := getAuthToken(props)
+	if err != nil {
+		return clientGame, err
 	}
 
+	game, gameError := getIgdbGameById(igdbId, props, authToken)
+	if gameError != nil {
+		return clientGame, gameError
+	}
 	if game == nil {
-		return nil, nil
+		return clientGame, errors.New("No IGDB game with id " + strconv.Itoa(igdbId))
 	}
+	return setGame(clientGame, game, props, authToken)
+}
+
+func setGame(clientGame domain.ClientGame, game *igdbGame, props properties.Properties, authToken string) (domain.ClientGame, error) {
+	if game == nil {
+		return clientGame, nil
+	}
+
+	artworks, artworkError := getGameArtworks(game, props, authToken)
+	if artworkError != nil {
+		return clientGame, artworkError
+	}
+	setMetadata(&clientGame, game)
+	clientGame.Artworks = artworks
+	return clientGame, nil
+}
 
+func getGameArtworks(game *igdbGame, props properties.Properties, authToken string) ([]domain.GameArtwork, error) {
 	fmt.Println("Fetching IGDB artworks ...")
-	var igdbArtworks []domain.IgdbGameArtwork
+	var igdbArtworks []domain.GameArtwork
 
 	covers, coverError := getIgdbArtworkByGameId(domain.Cover, game.Id, props, authToken)
 	if coverError == nil {
@@ -99,7 +145,9 @@ func GetGameArtworks(clientGame domain.ClientGame, props properties.Properties)
 	}
 
 	fmt.Println("Fetching IGDB artworks success!")
-	return igdbArtworks, nil
+	// Artworks and screenshots are fetched both by game and by the ids listed on the game, which mostly returns the same
+	// artworks twice
+	return artwork.Dedupe(igdbArtworks), nil
 }
 
 func getAuthToken(props properties.Properties) (string, error) {
@@ -122,7 +170,7 @@ func getAuthToken(props properties.Properties) (string, error) {
 func getIgdbGame(clientGame domain.ClientGame, props properties.Properties, authToken string) (*igdbGame, error) {
 	fmt.Println("Fetching IGDB game....")
 	igdbClient := resty.New()
-	body := "search \"" + strings.ReplaceAll(clientGame.Name, "®", "") + "\"; fields name,artworks,screenshots;"
+	body := "search \"" + strings.ReplaceAll(clientGame.Name, "®", "") + "\"; fields " + gameFields + ";"
 	igdbResp, errIgdb := igdbClient.R().
 		EnableTrace().
 		SetAuthToken(authToken).
@@ -144,6 +192,30 @@ func getIgdbGame(clientGame domain.ClientGame, props properties.Properties, auth
 	}
 }
 
+func getIgdbGameById(igdbId int, props properties.Properties, authToken string) (*igdbGame, error) {
+	fmt.Println("Fetching IGDB game....")
+	igdbResp, errIgdb := resty.New().R().
+		SetAuthToken(authToken).
+		SetHeader("Client-ID", props.MustGet("igdb.client.id")).
+		SetBody("fields " + gameFields + "; where id = " + strconv.Itoa(igdbId) + ";").
+		SetResult([]igdbGame{}).
+		Post("https://api.igdb.com/v4/games/")
+	if errIgdb != nil {
+		fmt.Println("Fetching IGDB games failed!")
+		return nil, errIgdb
+	}
+	if igdbResp.StatusCode() < 200 || igdbResp.StatusCode() > 299 {
+		fmt.Println("Fetching IGDB games failed!")
+		return nil, errors.New("Fetching IGDB games failed! Response Code: " + strconv.Itoa(igdbResp.StatusCode()) + " Response Message: " + igdbResp.String())
+	}
+	fmt.Println("Fetching IGDB games success!")
+	igdbResults := *igdbResp.Result().(*[]igdbGame)
+	if len(igdbResults) == 0 {
+		return nil, nil
+	}
+	return &igdbResults[0], nil
+}
+
 /**
 IGDB returns a list of results with titles names close to the search string. That means the search will return
 sequels and closely names titles. Here we do a fuzzy name match to get the one title we really need.
@@ -182,8 +254,8 @@ func findClosesResultMatch(clientGame domain.ClientGame, igdbResults *[]igdbGame
 	return &topRankGame
 }
 
-func getIgdbArtworksFromIds(artType domain.ArtworkType, artworkIds []int, props properties.Properties, authToken string) ([]domain.IgdbGameArtwork, error) {
-	var artworks []domain.IgdbGameArtwork
+func getIgdbArtworksFromIds(artType domain.ArtworkType, artworkIds []int, props properties.Properties, authToken string) ([]domain.GameArtwork, error) {
+	var artworks []domain.GameArtwork
 	for _, artworkId := range artworkIds {
 		igdbArtworks, artworkError := getIgdbArtworkById(artType, artworkId, props, authToken)
 		if artworkError == nil {
@@ -197,27 +269,32 @@ func getIgdbArtworksFromIds(artType domain.ArtworkType, artworkIds []int, props
 	return artworks, nil
 }
 
-func getIgdbArtworkByGameId(artType domain.ArtworkType, gameId int, props properties.Properties, authToken string) (*[]domain.IgdbGameArtwork, error) {
-	query := "fields image_id; where game = " + strconv.Itoa(gameId) + " & animated = false;"
+func getIgdbArtworkByGameId(artType domain.ArtworkType, gameId int, props properties.Properties, authToken string) (*[]domain.GameArtwork, error) {
+	query := "fields " + artworkFields + "; where game = " + strconv.Itoa(gameId) + " & animated = false;"
 	return getIgdbArtwork(artType, query, props, authToken)
 }
 
-func getIgdbArtworkById(artType domain.ArtworkType, artworkId int, props properties.Properties, authToken string) (*[]domain.IgdbGameArtwork, error) {
-	query := "fields image_id; where id = " + strconv.Itoa(artworkId) + ";"
+func getIgdbArtworkById(artType domain.ArtworkType, artworkId int, props properties.Properties, authToken string) (*[]domain.GameArtwork, error) {
+	query := "fields " + artworkFields + "; where id = " + strconv.Itoa(artworkId) + ";"
 	return getIgdbArtwork(artType, query, props, authToken)
 }
 
-func getIgdbArtwork(artType domain.ArtworkType, query string, props properties.Properties, authToken string) (*[]domain.IgdbGameArtwork, error) {
+func getIgdbArtwork(artType domain.ArtworkType, query string, props properties.Properties, authToken string) (*[]domain.GameArtwork, error) {
 	igdbClient := resty.New()
 	igdbArtworksResp, errIgdbArtworks := igdbClient.R().
 		EnableTrace().
 		SetAuthToken(authToken).
 		SetHeader("Client-ID", props.MustGet("igdb.client.id")).
 		SetBody(query).
-		SetResult([]domain.IgdbGameArtwork{}).
+		SetResult([]domain.GameArtwork{}).
 		Post("https://api.igdb.com/v4/" + artType.String())
 	if errIgdbArtworks == nil {
-		artworksResp := igdbArtworksResp.Result().(*[]domain.IgdbGameArtwork)
+		artworksResp := igdbArtworksResp.Result().(*[]domain.GameArtwork)
+		// Covers, artworks and screenshots share the same fields so the type is only known from the endpoint
+		for i := range *artworksResp {
+			(*artworksResp)[i].Type = artType
+			(*artworksResp)[i].Provider = domain.IgdbProvider
+		}
 		return artworksResp, nil
 	} else {
 		return nil, errIgdbArtworks
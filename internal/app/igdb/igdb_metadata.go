@@ -0,0 +1,158 @@
+package igdb
+
+import (
+	"github.com/magiconair/properties"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+// gameFields are the game fields requested from IGDB. The dotted fields expand the referenced entities so their names
+// come back in the same request.
+const gameFields = "name,artworks,screenshots,genres.name,themes.name,game_modes.name,first_release_date," +
+	"aggregated_rating,rating,summary,storyline,franchise.name,collection.name," +
+	"involved_companies.company.name,involved_companies.developer,involved_companies.publisher," +
+	"age_ratings.category,age_ratings.rating"
+
+const artworkFields = "image_id,width,height,animated,alpha_channel"
+
+type igdbNamed struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type igdbInvolvedCompany struct {
+	Company   igdbNamed `json:"company"`
+	Developer bool      `json:"developer"`
+	Publisher bool      `json:"publisher"`
+}
+
+type igdbAgeRating struct {
+	Category int `json:"category"`
+	Rating   int `json:"rating"`
+}
+
+var ageRatingOrganizations = map[int]string{
+	1: "ESRB",
+	2: "PEGI",
+	3: "CERO",
+	4: "USK",
+	5: "GRAC",
+	6: "CLASS_IND",
+	7: "ACB",
+}
+
+type ageRatingValue struct {
+	rating string
+	age    int
+}
+
+// ageRatingValues maps the IGDB age rating enum to the rating name and the minimum age it stands for.
+var ageRatingValues = map[int]ageRatingValue{
+	1:  {"3", 3},
+	2:  {"7", 7},
+	3:  {"12", 12},
+	4:  {"16", 16},
+	5:  {"18", 18},
+	6:  {"RP", -1},
+	7:  {"EC", 3},
+	8:  {"E", 0},
+	9:  {"E10", 10},
+	10: {"T", 13},
+	11: {"M", 17},
+	12: {"AO", 18},
+	13: {"A", 0},
+	14: {"B", 12},
+	15: {"C", 15},
+	16: {"D", 17},
+	17: {"Z", 18},
+	18: {"0", 0},
+	19: {"6", 6},
+	20: {"12", 12},
+	21: {"16", 16},
+	22: {"18", 18},
+	23: {"ALL", 0},
+	24: {"12", 12},
+	25: {"15", 15},
+	26: {"18", 18},
+	27: {"TESTING", -1},
+	28: {"L", 0},
+	29: {"10", 10},
+	30: {"12", 12},
+	31: {"14", 14},
+	32: {"16", 16},
+	33: {"18", 18},
+	34: {"G", 0},
+	35: {"PG", 8},
+	36: {"M", 15},
+	37: {"MA15", 15},
+	38: {"R18", 18},
+	39: {"RC", -1},
+}
+
+// GetMetadata returns the client game with the metadata of its IGDB game, keeping its artworks. Games IGDB does not
+// know are returned as they are.
+func GetMetadata(clientGame domain.ClientGame, props properties.Properties) (domain.ClientGame, error) {
+	authToken, err := getAuthToken(props)
+	if err != nil {
+		return clientGame, err
+	}
+	game, gameError := getIgdbGame(clientGame, props, authToken)
+	if gameError != nil || game == nil {
+		return clientGame, gameError
+	}
+	setMetadata(&clientGame, game)
+	return clientGame, nil
+}
+
+func setMetadata(clientGame *domain.ClientGame, game *igdbGame) {
+	clientGame.IgdbId = game.Id
+	clientGame.Genres = getNames(game.Genres)
+	clientGame.Themes = getNames(game.Themes)
+	clientGame.GameModes = getNames(game.GameModes)
+	clientGame.FirstReleaseDate = time.Time{}
+	if game.FirstReleaseDate != 0 {
+		clientGame.FirstReleaseDate = time.Unix(game.FirstReleaseDate, 0).UTC()
+	}
+	clientGame.AggregatedRating = game.AggregatedRating
+	clientGame.UserRating = game.Rating
+	clientGame.Summary = game.Summary
+	clientGame.Storyline = game.Storyline
+	clientGame.Franchise = ""
+	if game.Franchise != nil {
+		clientGame.Franchise = game.Franchise.Name
+	}
+	clientGame.Collection = ""
+	if game.Collection != nil {
+		clientGame.Collection = game.Collection.Name
+	}
+
+	clientGame.InvolvedCompanies = nil
+	for _, involvedCompany := range game.InvolvedCompanies {
+		clientGame.InvolvedCompanies = append(clientGame.InvolvedCompanies, domain.InvolvedCompany{
+			Name:      involvedCompany.Company.Name,
+			Developer: involvedCompany.Developer,
+			Publisher: involvedCompany.Publisher,
+		})
+	}
+
+	clientGame.AgeRatings = nil
+	for _, ageRating := range game.AgeRatings {
+		organization, organizationFound := ageRatingOrganizations[ageRating.Category]
+		value, valueFound := ageRatingValues[ageRating.Rating]
+		if organizationFound && valueFound {
+			clientGame.AgeRatings = append(clientGame.AgeRatings, domain.AgeRating{
+				Organization: organization,
+				Rating:       value.rating,
+				Age:          value.age,
+			})
+		}
+	}
+}
+
+func getNames(namedEntities []igdbNamed) []string {
+	var names []string
+	for _, namedEntity := range namedEntities {
+		names = append(names, namedEntity.Name)
+	}
+	return names
+}
@@ -0,0 +1,138 @@
+package imagecache
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	_ "golang.org/x/image/webp"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Cache keeps downloaded images on disk so each artwork is only fetched once, and keeps the most recently decoded
+// images in memory so images shown again are not decoded again.
+type Cache struct {
+	directory  string
+	maxDecoded int
+	mutex      sync.Mutex
+	decoded    map[string]*list.Element
+	recent     *list.List
+}
+
+type decodedImage struct {
+	url   string
+	image image.Image
+}
+
+func New(directory string, maxDecoded int) *Cache {
+	return &Cache{
+		directory:  directory,
+		maxDecoded: maxDecoded,
+		decoded:    make(map[string]*list.Element),
+		recent:     list.New(),
+	}
+}
+
+// GetImage returns the decoded image of the URL, downloading it first when it is not cached yet.
+func (cache *Cache) GetImage(imageUrl string) (image.Image, error) {
+	cache.mutex.Lock()
+	if element, found := cache.decoded[imageUrl]; found {
+		cache.recent.MoveToFront(element)
+		cache.mutex.Unlock()
+		return element.Value.(*decodedImage).image, nil
+	}
+	cache.mutex.Unlock()
+
+	imagePath, pathErr := cache.GetPath(imageUrl)
+	if pathErr != nil {
+		return nil, pathErr
+	}
+	imageFile, openErr := os.Open(imagePath)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer imageFile.Close()
+	decoded, _, decodeErr := image.Decode(imageFile)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if _, found := cache.decoded[imageUrl]; !found {
+		cache.decoded[imageUrl] = cache.recent.PushFront(&decodedImage{url: imageUrl, image: decoded})
+		for cache.recent.Len() > cache.maxDecoded {
+			oldest := cache.recent.Back()
+			cache.recent.Remove(oldest)
+			delete(cache.decoded, oldest.Value.(*decodedImage).url)
+		}
+	}
+	return decoded, nil
+}
+
+// GetPath returns the path of the cached image file of the URL, downloading it first when it is not cached yet. Local
+// file URLs are not copied to the cache, their own path is returned.
+func (cache *Cache) GetPath(imageUrl string) (string, error) {
+	if parsedUrl, parseErr := url.Parse(imageUrl); parseErr == nil && parsedUrl.Scheme == "file" {
+		return filepath.FromSlash(parsedUrl.Path), nil
+	}
+	imagePath := filepath.Join(cache.directory, GetFileName(imageUrl))
+	if _, statErr := os.Stat(imagePath); statErr == nil {
+		return imagePath, nil
+	}
+
+	mkdirErr := os.MkdirAll(cache.directory, 0755)
+	if mkdirErr != nil {
+		return "", mkdirErr
+	}
+	imageResource, imgResErr := http.Get(imageUrl)
+	if imgResErr != nil {
+		return "", imgResErr
+	}
+	defer imageResource.Body.Close()
+	if imageResource.StatusCode < 200 || imageResource.StatusCode > 299 {
+		return "", errors.New("Failed to download image " + imageUrl + " Response Code: " + strconv.Itoa(imageResource.StatusCode))
+	}
+
+	// The image is written to a temporary file first so an interrupted download is never taken for a cached image
+	tempFile, tempErr := os.CreateTemp(cache.directory, "download-*")
+	if tempErr != nil {
+		return "", tempErr
+	}
+	_, copyErr := io.Copy(tempFile, imageResource.Body)
+	closeErr := tempFile.Close()
+	if copyErr != nil || closeErr != nil {
+		os.Remove(tempFile.Name())
+		if copyErr != nil {
+			return "", copyErr
+		}
+		return "", closeErr
+	}
+	renameErr := os.Rename(tempFile.Name(), imagePath)
+	if renameErr != nil {
+		os.Remove(tempFile.Name())
+		return "", renameErr
+	}
+	return imagePath, nil
+}
+
+// GetFileName is the name of the cache file of the URL, a hash of the URL keeping the extension of the image.
+func GetFileName(imageUrl string) string {
+	hash := sha1.Sum([]byte(imageUrl))
+	extension := ""
+	parsedUrl, parseErr := url.Parse(imageUrl)
+	if parseErr == nil {
+		extension = path.Ext(parsedUrl.Path)
+	}
+	return hex.EncodeToString(hash[:]) + extension
+}
@@ -0,0 +1,49 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"github.com/magiconair/properties"
+	"os/exec"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+const defaultSteamCommand = "xdg-open steam://rungameid/{source-id}"
+
+// Launch starts the game through the command configured for its source. Commands are templates where {source-id} and
+// {name} are replaced by the game values, e.g. visualizer.launcher.steam.command=xdg-open steam://rungameid/{source-id}
+func Launch(game domain.ClientGame, props properties.Properties) error {
+	commandTemplate := getCommandTemplate(game.Source, props)
+	if commandTemplate == "" {
+		return errors.New("No launcher command configured for source " + game.Source.String())
+	}
+
+	// The template is split before the values are replaced so a game name with spaces stays a single argument
+	var args []string
+	for _, arg := range strings.Fields(commandTemplate) {
+		arg = strings.ReplaceAll(arg, "{source-id}", game.SourceId)
+		arg = strings.ReplaceAll(arg, "{name}", game.Name)
+		args = append(args, arg)
+	}
+
+	fmt.Println("Launching " + game.Name + " ...")
+	launchCommand := exec.Command(args[0], args[1:]...)
+	startErr := launchCommand.Start()
+	if startErr != nil {
+		return startErr
+	}
+	// The launched process is not waited on by the visualizer, but it still needs to be reaped once it exits
+	go launchCommand.Wait()
+	return nil
+}
+
+func getCommandTemplate(source domain.GameSource, props properties.Properties) string {
+	switch source {
+	case domain.Steam:
+		return props.GetString("visualizer.launcher.steam.command", defaultSteamCommand)
+	case domain.ItchIo:
+		return props.GetString("visualizer.launcher.itchio.command", "")
+	}
+	return ""
+}
@@ -0,0 +1,234 @@
+package localart
+
+import (
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+}
+
+// namedArtworkTypes are the files directly in a game directory, by file name without extension.
+var namedArtworkTypes = map[string]domain.ArtworkType{
+	"cover": domain.Cover,
+	"hero":  domain.Hero,
+	"logo":  domain.Logo,
+}
+
+// Library holds the hand-picked artworks found under the override directory, laid out as
+//
+//	<root>/<source>/<source-id>/cover.jpg
+//	<root>/<source>/<source-id>/backgrounds/*.{jpg,png,webp}
+//
+// A game directory can also be named after the game instead of its source id, it is used when no directory matches the
+// id. Names match ignoring case, spaces and punctuation.
+type Library struct {
+	root   string
+	mutex  sync.RWMutex
+	byId   map[string][]domain.GameArtwork
+	byName map[string][]domain.GameArtwork
+}
+
+func New(root string) *Library {
+	return &Library{
+		root:   root,
+		byId:   make(map[string][]domain.GameArtwork),
+		byName: make(map[string][]domain.GameArtwork),
+	}
+}
+
+// Scan reads the override directory again, replacing the artworks found by the previous scan.
+func (library *Library) Scan() error {
+	byId := make(map[string][]domain.GameArtwork)
+	byName := make(map[string][]domain.GameArtwork)
+	sourceDirs, readErr := os.ReadDir(library.root)
+	if readErr != nil {
+		return readErr
+	}
+	for _, sourceDir := range sourceDirs {
+		if !sourceDir.IsDir() {
+			continue
+		}
+		gameDirs, gameReadErr := os.ReadDir(filepath.Join(library.root, sourceDir.Name()))
+		if gameReadErr != nil {
+			return gameReadErr
+		}
+		for _, gameDir := range gameDirs {
+			if !gameDir.IsDir() {
+				continue
+			}
+			artworks := scanGameDir(filepath.Join(library.root, sourceDir.Name(), gameDir.Name()))
+			if len(artworks) == 0 {
+				continue
+			}
+			source := strings.ToLower(sourceDir.Name())
+			byId[source+"/"+gameDir.Name()] = artworks
+			byName[source+"/"+normalizeName(gameDir.Name())] = artworks
+		}
+	}
+
+	library.mutex.Lock()
+	defer library.mutex.Unlock()
+	library.byId = byId
+	library.byName = byName
+	return nil
+}
+
+func scanGameDir(gameDir string) []domain.GameArtwork {
+	var artworks []domain.GameArtwork
+	files, _ := os.ReadDir(gameDir)
+	for _, file := range files {
+		extension := strings.ToLower(filepath.Ext(file.Name()))
+		artType, named := namedArtworkTypes[strings.ToLower(strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())))]
+		if !file.IsDir() && named && imageExtensions[extension] {
+			artworks = append(artworks, newLocalArtwork(filepath.Join(gameDir, file.Name()), artType))
+		}
+	}
+	backgroundFiles, _ := os.ReadDir(filepath.Join(gameDir, "backgrounds"))
+	for _, file := range backgroundFiles {
+		if !file.IsDir() && imageExtensions[strings.ToLower(filepath.Ext(file.Name()))] {
+			artworks = append(artworks, newLocalArtwork(filepath.Join(gameDir, "backgrounds", file.Name()), domain.Artwork))
+		}
+	}
+	return artworks
+}
+
+// newLocalArtwork refers to the file by a file URL holding its modification time, so an edited file is not taken for
+// the image decoded before the edit.
+func newLocalArtwork(path string, artType domain.ArtworkType) domain.GameArtwork {
+	absolutePath, absErr := filepath.Abs(path)
+	if absErr != nil {
+		absolutePath = path
+	}
+	fileUrl := url.URL{Scheme: "file", Path: filepath.ToSlash(absolutePath)}
+	if fileInfo, statErr := os.Stat(path); statErr == nil {
+		fileUrl.RawQuery = "modified=" + strconv.FormatInt(fileInfo.ModTime().Unix(), 10)
+	}
+	return domain.GameArtwork{
+		Type:     artType,
+		Provider: domain.LocalProvider,
+		ImageUrl: fileUrl.String(),
+	}
+}
+
+func normalizeName(name string) string {
+	var normalized strings.Builder
+	for _, character := range strings.ToLower(name) {
+		if unicode.IsLetter(character) || unicode.IsDigit(character) {
+			normalized.WriteRune(character)
+		}
+	}
+	return normalized.String()
+}
+
+// GetArtworks returns the local artworks of the game, matched by source id first and by name second.
+func (library *Library) GetArtworks(game domain.ClientGame) []domain.GameArtwork {
+	library.mutex.RLock()
+	defer library.mutex.RUnlock()
+	source := strings.ToLower(game.Source.String())
+	if artworks, found := library.byId[source+"/"+game.SourceId]; found {
+		return artworks
+	}
+	return library.byName[source+"/"+normalizeName(game.Name)]
+}
+
+// Apply returns the game with its local artworks in place of the other artworks of the same kind: a local cover
+// replaces the other covers and local backgrounds replace the other backgrounds.
+func (library *Library) Apply(game domain.ClientGame) domain.ClientGame {
+	localArtworks := library.GetArtworks(game)
+	if len(localArtworks) == 0 {
+		return game
+	}
+	localTypes := make(map[domain.ArtworkType]bool)
+	for _, artwork := range localArtworks {
+		localTypes[getKind(artwork.Type)] = true
+	}
+	artworks := append([]domain.GameArtwork{}, localArtworks...)
+	for _, artwork := range game.Artworks {
+		if !localTypes[getKind(artwork.Type)] {
+			artworks = append(artworks, artwork)
+		}
+	}
+	game.Artworks = artworks
+	return game
+}
+
+// getKind groups the artwork types shown as backgrounds together.
+func getKind(artType domain.ArtworkType) domain.ArtworkType {
+	switch artType {
+	case domain.ScreenShot, domain.Hero, domain.UnknownArtworkType:
+		return domain.Artwork
+	}
+	return artType
+}
+
+// Watch scans the override directory again whenever files under it change. Closing the returned watcher stops it.
+func (library *Library) Watch() (io.Closer, error) {
+	watcher, watcherErr := fsnotify.NewWatcher()
+	if watcherErr != nil {
+		return nil, watcherErr
+	}
+	addErr := addWatches(watcher, library.root)
+	if addErr != nil {
+		watcher.Close()
+		return nil, addErr
+	}
+
+	go func() {
+		// Copying a folder of artworks fires many events, the scan waits until they stop coming
+		var rescan <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&fsnotify.Create != 0 {
+					if fileInfo, statErr := os.Stat(event.Name); statErr == nil && fileInfo.IsDir() {
+						addWatches(watcher, event.Name)
+					}
+				}
+				rescan = time.After(500 * time.Millisecond)
+			case watchErr, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Println("Watching local artworks failed! " + watchErr.Error())
+			case <-rescan:
+				rescan = nil
+				scanErr := library.Scan()
+				if scanErr != nil {
+					fmt.Println("Scanning local artworks failed! " + scanErr.Error())
+				}
+			}
+		}
+	}()
+	return watcher, nil
+}
+
+// addWatches watches the directory and its subdirectories, fsnotify does not watch subdirectories by itself.
+func addWatches(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, fileInfo os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if fileInfo.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
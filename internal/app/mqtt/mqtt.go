@@ -0,0 +1,251 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/magiconair/properties"
+	"strings"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/filter"
+	"vg-cover-screen-saver-go/internal/app/slideshow"
+)
+
+const (
+	onlinePayload  = "online"
+	offlinePayload = "offline"
+)
+
+// newClient creates the client New connects with, tests replace it with a client without broker.
+var newClient = paho.NewClient
+
+// Config is the broker to connect to and the topics to use. Topic is the base of the topics of the visualizer,
+// DiscoveryPrefix the one Home Assistant listens for discovery messages on.
+type Config struct {
+	Enabled         bool
+	Broker          string
+	ClientId        string
+	Username        string
+	Password        string
+	Topic           string
+	DiscoveryPrefix string
+	NodeId          string
+}
+
+// LoadConfig reads the broker credentials from the secret properties and the rest from the main properties.
+func LoadConfig(props properties.Properties, secretProps properties.Properties) Config {
+	return Config{
+		Enabled:         props.GetBool("visualizer.mqtt.enabled", false),
+		Broker:          props.GetString("visualizer.mqtt.broker", "tcp://localhost:1883"),
+		ClientId:        props.GetString("visualizer.mqtt.client.id", "vg-library-visualizer"),
+		Username:        secretProps.GetString("mqtt.username", ""),
+		Password:        secretProps.GetString("mqtt.password", ""),
+		Topic:           strings.TrimSuffix(props.GetString("visualizer.mqtt.topic", "vg-library-visualizer"), "/"),
+		DiscoveryPrefix: strings.TrimSuffix(props.GetString("visualizer.mqtt.discovery.prefix", "homeassistant"), "/"),
+		NodeId:          props.GetString("visualizer.mqtt.node.id", "vg_library_visualizer"),
+	}
+}
+
+// Publisher publishes what the slideshow shows to an MQTT broker and sends the commands it receives to the slideshow.
+// Home Assistant finds the visualizer through MQTT discovery: the shown game as sensor, next and previous buttons, a
+// pause switch and the library filter as text.
+//
+//	<topic>/availability   online or offline, retained
+//	<topic>/state          the shown game as JSON, retained
+//	<topic>/command        next, previous, pause, resume or toggle-pause
+//	<topic>/filter         the library filter expression, retained
+//	<topic>/filter/set     a library filter expression to apply
+type Publisher struct {
+	config     Config
+	controller *slideshow.Controller
+	client     paho.Client
+	stop       func()
+}
+
+func New(config Config, controller *slideshow.Controller) *Publisher {
+	publisher := &Publisher{config: config, controller: controller}
+	options := paho.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(config.ClientId).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetWill(publisher.topic("availability"), offlinePayload, 1, true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(publisher.onConnect)
+	publisher.client = newClient(options)
+	return publisher
+}
+
+// Start connects to the broker and publishes the slideshow events until Close. A broker not reachable yet is retried
+// in the background.
+func (publisher *Publisher) Start() error {
+	if publisher.config.Broker == "" {
+		return errors.New("No MQTT broker configured in visualizer.mqtt.broker")
+	}
+	connectToken := publisher.client.Connect()
+	if connectToken.WaitTimeout(10*time.Second) && connectToken.Error() != nil {
+		return connectToken.Error()
+	}
+	events, cancel := publisher.controller.Subscribe()
+	publisher.stop = cancel
+	go func() {
+		for event := range events {
+			publisher.publishState(event)
+		}
+	}()
+	return nil
+}
+
+// Close marks the visualizer offline and disconnects from the broker.
+func (publisher *Publisher) Close() {
+	if publisher.stop != nil {
+		publisher.stop()
+	}
+	if publisher.client.IsConnected() {
+		publisher.client.Publish(publisher.topic("availability"), 1, true, offlinePayload).WaitTimeout(time.Second)
+	}
+	publisher.client.Disconnect(250)
+}
+
+func (publisher *Publisher) topic(name string) string {
+	return publisher.config.Topic + "/" + name
+}
+
+// onConnect runs on every connect, reconnects included, as a broker without persistence forgets the subscriptions and
+// retained messages when it restarts.
+func (publisher *Publisher) onConnect(client paho.Client) {
+	client.Subscribe(publisher.topic("command"), 1, publisher.onCommand)
+	client.Subscribe(publisher.topic("filter/set"), 1, publisher.onFilter)
+	// Home Assistant announces its restarts, the discovery messages are sent again for it
+	client.Subscribe(publisher.config.DiscoveryPrefix+"/status", 1, func(client paho.Client, message paho.Message) {
+		if string(message.Payload()) == onlinePayload {
+			publisher.publishDiscovery()
+		}
+	})
+	publisher.publishDiscovery()
+	client.Publish(publisher.topic("availability"), 1, true, onlinePayload)
+	client.Publish(publisher.topic("filter"), 1, true, publisher.controller.Filter())
+	if event, shown := publisher.controller.Current(); shown {
+		publisher.publishState(event)
+	}
+}
+
+var commandKinds = map[string]slideshow.CommandKind{
+	"next":         slideshow.Next,
+	"previous":     slideshow.Previous,
+	"pause":        slideshow.Pause,
+	"resume":       slideshow.Resume,
+	"toggle-pause": slideshow.TogglePause,
+}
+
+func (publisher *Publisher) onCommand(client paho.Client, message paho.Message) {
+	kind, known := commandKinds[strings.ToLower(strings.TrimSpace(string(message.Payload())))]
+	if known {
+		publisher.controller.Send(slideshow.Command{Kind: kind})
+	}
+}
+
+// onFilter sends valid filters to the slideshow. Invalid filters are answered with the filter the slideshow keeps, so
+// the text in Home Assistant does not show a filter that is not applied.
+func (publisher *Publisher) onFilter(client paho.Client, message paho.Message) {
+	expression := strings.TrimSpace(string(message.Payload()))
+	if _, filterErr := filter.Parse(expression); filterErr != nil {
+		client.Publish(publisher.topic("filter"), 1, true, publisher.controller.Filter())
+		return
+	}
+	publisher.controller.Send(slideshow.Command{Kind: slideshow.SetFilter, Filter: expression})
+	client.Publish(publisher.topic("filter"), 1, true, expression)
+}
+
+// state is the event as Home Assistant reads it, the artwork doubles as the picture of the sensor.
+type state struct {
+	slideshow.Event
+	EntityPicture string `json:"entity_picture,omitempty"`
+}
+
+func (publisher *Publisher) publishState(event slideshow.Event) {
+	stateJson, _ := json.Marshal(state{Event: event, EntityPicture: event.ArtworkUrl})
+	publisher.client.Publish(publisher.topic("state"), 1, true, stateJson)
+}
+
+type device struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Model        string   `json:"model"`
+	Manufacturer string   `json:"manufacturer"`
+}
+
+// entity is the discovery config of one Home Assistant entity, only the fields of its component are set.
+type entity struct {
+	Name                string `json:"name"`
+	UniqueId            string `json:"unique_id"`
+	Icon                string `json:"icon,omitempty"`
+	AvailabilityTopic   string `json:"availability_topic"`
+	StateTopic          string `json:"state_topic,omitempty"`
+	ValueTemplate       string `json:"value_template,omitempty"`
+	JsonAttributesTopic string `json:"json_attributes_topic,omitempty"`
+	CommandTopic        string `json:"command_topic,omitempty"`
+	PayloadPress        string `json:"payload_press,omitempty"`
+	PayloadOn           string `json:"payload_on,omitempty"`
+	PayloadOff          string `json:"payload_off,omitempty"`
+	StateOn             string `json:"state_on,omitempty"`
+	StateOff            string `json:"state_off,omitempty"`
+	Device              device `json:"device"`
+}
+
+func (publisher *Publisher) publishDiscovery() {
+	visualizerDevice := device{
+		Identifiers:  []string{publisher.config.NodeId},
+		Name:         "Video Game Library Visualizer",
+		Model:        "vg-library-visualizer",
+		Manufacturer: "vg-library-visualizer",
+	}
+	newEntity := func(objectId string, name string, icon string) entity {
+		return entity{
+			Name:              name,
+			UniqueId:          publisher.config.NodeId + "_" + objectId,
+			Icon:              icon,
+			AvailabilityTopic: publisher.topic("availability"),
+			Device:            visualizerDevice,
+		}
+	}
+
+	nowShowing := newEntity("now_showing", "Now showing", "mdi:gamepad-variant")
+	nowShowing.StateTopic = publisher.topic("state")
+	nowShowing.ValueTemplate = "{{ value_json.name }}"
+	nowShowing.JsonAttributesTopic = publisher.topic("state")
+
+	next := newEntity("next", "Next game", "mdi:skip-next")
+	next.CommandTopic = publisher.topic("command")
+	next.PayloadPress = "next"
+
+	previous := newEntity("previous", "Previous game", "mdi:skip-previous")
+	previous.CommandTopic = publisher.topic("command")
+	previous.PayloadPress = "previous"
+
+	paused := newEntity("paused", "Paused", "mdi:pause")
+	paused.CommandTopic = publisher.topic("command")
+	paused.PayloadOn = "pause"
+	paused.PayloadOff = "resume"
+	paused.StateTopic = publisher.topic("state")
+	paused.ValueTemplate = "{{ 'ON' if value_json.paused else 'OFF' }}"
+	paused.StateOn = "ON"
+	paused.StateOff = "OFF"
+
+	libraryFilter := newEntity("filter", "Library filter", "mdi:filter")
+	libraryFilter.CommandTopic = publisher.topic("filter/set")
+	libraryFilter.StateTopic = publisher.topic("filter")
+
+	publisher.publishEntity("sensor", "now_showing", nowShowing)
+	publisher.publishEntity("button", "next", next)
+	publisher.publishEntity("button", "previous", previous)
+	publisher.publishEntity("switch", "paused", paused)
+	publisher.publishEntity("text", "filter", libraryFilter)
+}
+
+func (publisher *Publisher) publishEntity(component string, objectId string, config entity) {
+	configJson, _ := json.Marshal(config)
+	discoveryTopic := publisher.config.DiscoveryPrefix + "/" + component + "/" + publisher.config.NodeId + "/" + objectId + "/config"
+	publisher.client.Publish(discoveryTopic, 1, true, configJson)
+}
@@ -0,0 +1,226 @@
+package mqtt
+
+import (
+	"encoding/json"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"sync"
+	"testing"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/slideshow"
+)
+
+// fakeToken is a token completed right away.
+type fakeToken struct{}
+
+func (fakeToken) Wait() bool                     { return true }
+func (fakeToken) WaitTimeout(time.Duration) bool { return true }
+func (fakeToken) Done() <-chan struct{}          { done := make(chan struct{}); close(done); return done }
+func (fakeToken) Error() error                   { return nil }
+
+type fakeMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (message fakeMessage) Duplicate() bool   { return false }
+func (message fakeMessage) Qos() byte         { return 1 }
+func (message fakeMessage) Retained() bool    { return false }
+func (message fakeMessage) Topic() string     { return message.topic }
+func (message fakeMessage) MessageID() uint16 { return 0 }
+func (message fakeMessage) Payload() []byte   { return message.payload }
+func (message fakeMessage) Ack()              {}
+
+// fakeClient stands in for the broker: it connects right away, keeps the last message published on each topic and
+// delivers messages to the subscribed handlers.
+type fakeClient struct {
+	options   *paho.ClientOptions
+	mutex     sync.Mutex
+	connected bool
+	published map[string]string
+	handlers  map[string]paho.MessageHandler
+}
+
+func (client *fakeClient) IsConnected() bool      { return client.connected }
+func (client *fakeClient) IsConnectionOpen() bool { return client.connected }
+func (client *fakeClient) Disconnect(uint)        { client.connected = false }
+
+func (client *fakeClient) Connect() paho.Token {
+	client.connected = true
+	client.options.OnConnect(client)
+	return fakeToken{}
+}
+
+func (client *fakeClient) Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	switch value := payload.(type) {
+	case string:
+		client.published[topic] = value
+	case []byte:
+		client.published[topic] = string(value)
+	}
+	return fakeToken{}
+}
+
+func (client *fakeClient) Subscribe(topic string, qos byte, callback paho.MessageHandler) paho.Token {
+	client.handlers[topic] = callback
+	return fakeToken{}
+}
+
+func (client *fakeClient) SubscribeMultiple(filters map[string]byte, callback paho.MessageHandler) paho.Token {
+	for topic := range filters {
+		client.handlers[topic] = callback
+	}
+	return fakeToken{}
+}
+
+func (client *fakeClient) Unsubscribe(topics ...string) paho.Token {
+	for _, topic := range topics {
+		delete(client.handlers, topic)
+	}
+	return fakeToken{}
+}
+
+func (client *fakeClient) AddRoute(topic string, callback paho.MessageHandler) {}
+
+func (client *fakeClient) OptionsReader() paho.ClientOptionsReader {
+	return paho.ClientOptionsReader{}
+}
+
+func (client *fakeClient) deliver(topic string, payload string) {
+	client.handlers[topic](client, fakeMessage{topic: topic, payload: []byte(payload)})
+}
+
+func (client *fakeClient) message(topic string) (string, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	payload, found := client.published[topic]
+	return payload, found
+}
+
+// startPublisher starts a publisher with the default config on a fake client.
+func startPublisher(t *testing.T) (*fakeClient, *slideshow.Controller) {
+	var client *fakeClient
+	newClient = func(options *paho.ClientOptions) paho.Client {
+		client = &fakeClient{options: options, published: make(map[string]string), handlers: make(map[string]paho.MessageHandler)}
+		return client
+	}
+	t.Cleanup(func() { newClient = paho.NewClient })
+
+	controller := slideshow.New(10)
+	config := Config{Broker: "tcp://localhost:1883", Topic: "visualizer", DiscoveryPrefix: "homeassistant", NodeId: "node"}
+	publisher := New(config, controller)
+	if err := publisher.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(publisher.Close)
+	return client, controller
+}
+
+func TestDiscovery(t *testing.T) {
+	client, _ := startPublisher(t)
+	tests := []struct {
+		topic        string
+		commandTopic string
+		stateTopic   string
+		payloadPress string
+		payloadOn    string
+	}{
+		{"homeassistant/sensor/node/now_showing/config", "", "visualizer/state", "", ""},
+		{"homeassistant/button/node/next/config", "visualizer/command", "", "next", ""},
+		{"homeassistant/button/node/previous/config", "visualizer/command", "", "previous", ""},
+		{"homeassistant/switch/node/paused/config", "visualizer/command", "visualizer/state", "", "pause"},
+		{"homeassistant/text/node/filter/config", "visualizer/filter/set", "visualizer/filter", "", ""},
+	}
+	for _, test := range tests {
+		payload, found := client.message(test.topic)
+		if !found {
+			t.Errorf("No discovery message on %s", test.topic)
+			continue
+		}
+		var config entity
+		if err := json.Unmarshal([]byte(payload), &config); err != nil {
+			t.Errorf("Discovery message on %s is not JSON: %v", test.topic, err)
+			continue
+		}
+		if config.CommandTopic != test.commandTopic || config.StateTopic != test.stateTopic ||
+			config.PayloadPress != test.payloadPress || config.PayloadOn != test.payloadOn {
+			t.Errorf("%s = %+v, want command topic %q, state topic %q, press %q and on %q", test.topic, config,
+				test.commandTopic, test.stateTopic, test.payloadPress, test.payloadOn)
+		}
+		if config.AvailabilityTopic != "visualizer/availability" || config.Device.Identifiers[0] != "node" {
+			t.Errorf("%s availability topic %q and device %v, want visualizer/availability and node", test.topic,
+				config.AvailabilityTopic, config.Device.Identifiers)
+		}
+	}
+	if availability, _ := client.message("visualizer/availability"); availability != onlinePayload {
+		t.Errorf("availability = %q, want %q", availability, onlinePayload)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		payload string
+		kind    slideshow.CommandKind
+	}{
+		{"next", slideshow.Next},
+		{"previous", slideshow.Previous},
+		{" NEXT\n", slideshow.Next},
+	}
+	for _, test := range tests {
+		client, controller := startPublisher(t)
+		client.deliver("visualizer/command", test.payload)
+		if command, interrupted := controller.Wait(time.Second); !interrupted || command.Kind != test.kind {
+			t.Errorf("command %q = %+v %v, want kind %v", test.payload, command, interrupted, test.kind)
+		}
+	}
+
+	client, controller := startPublisher(t)
+	client.deliver("visualizer/command", "pause")
+	client.deliver("visualizer/command", "next")
+	if command, _ := controller.Wait(time.Second); command.Kind != slideshow.Next || !controller.Paused() {
+		t.Errorf("pause then next = %+v paused %v, want next and paused", command, controller.Paused())
+	}
+
+	client, controller = startPublisher(t)
+	client.deliver("visualizer/command", "shuffle")
+	if command, interrupted := controller.Wait(50 * time.Millisecond); interrupted {
+		t.Errorf("unknown command = %+v, want no command", command)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		expression string
+		valid      bool
+	}{
+		{"favourite", true},
+		{"developer=Valve and playtime<60", true},
+		{"", true},
+		{"developer=", false},
+		{"colour=red", false},
+		{"(favourite", false},
+	}
+	for _, test := range tests {
+		client, controller := startPublisher(t)
+		controller.SetFilter("installed")
+		client.deliver("visualizer/filter/set", test.expression)
+		command, interrupted := controller.Wait(50 * time.Millisecond)
+		published, _ := client.message("visualizer/filter")
+		if test.valid {
+			if !interrupted || command.Kind != slideshow.SetFilter || command.Filter != test.expression {
+				t.Errorf("filter %q = %+v %v, want it sent to the slideshow", test.expression, command, interrupted)
+			}
+			if published != test.expression {
+				t.Errorf("filter %q published %q, want the filter", test.expression, published)
+			}
+		} else {
+			if interrupted {
+				t.Errorf("invalid filter %q = %+v, want no command", test.expression, command)
+			}
+			if published != "installed" {
+				t.Errorf("invalid filter %q published %q, want the kept filter", test.expression, published)
+			}
+		}
+	}
+}
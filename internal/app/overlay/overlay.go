@@ -0,0 +1,207 @@
+package overlay
+
+import (
+	"fmt"
+	"github.com/magiconair/properties"
+	"html"
+	"image/color"
+	"strings"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/palette"
+)
+
+type Position int
+
+const (
+	BottomLeft Position = iota
+	BottomRight
+	TopLeft
+	TopRight
+)
+
+func ParsePosition(position string) Position {
+	switch strings.ToLower(strings.TrimSpace(position)) {
+	case "bottom-right":
+		return BottomRight
+	case "top-left":
+		return TopLeft
+	case "top-right":
+		return TopRight
+	}
+	return BottomLeft
+}
+
+type Config struct {
+	Enabled          bool
+	Position         Position
+	FontSize         float32
+	Fields           []string
+	HideAfter        time.Duration
+	Width            float32
+	DescriptionLines int
+	Opacity          uint8
+	Contrast         float64
+}
+
+func LoadConfig(props properties.Properties) Config {
+	var fields []string
+	for _, field := range strings.Split(props.GetString("visualizer.overlay.fields", "name,developers,description,playtime"), ",") {
+		if strings.TrimSpace(field) != "" {
+			fields = append(fields, strings.TrimSpace(field))
+		}
+	}
+	return Config{
+		Enabled:          props.GetBool("visualizer.overlay.enabled", false),
+		Position:         ParsePosition(props.GetString("visualizer.overlay.position", "bottom-left")),
+		FontSize:         float32(props.GetFloat64("visualizer.overlay.font.size", 16)),
+		Fields:           fields,
+		HideAfter:        time.Second * time.Duration(props.GetInt("visualizer.overlay.hide.seconds", 0)),
+		Width:            float32(props.GetFloat64("visualizer.overlay.width", 0.35)),
+		DescriptionLines: props.GetInt("visualizer.overlay.description.lines", 4),
+		Opacity:          uint8(clampInt(props.GetInt("visualizer.overlay.opacity", 180), 0, 255)),
+		Contrast:         props.GetFloat64("visualizer.overlay.contrast", 4.5),
+	}
+}
+
+func clampInt(value int, minimum int, maximum int) int {
+	if value < minimum {
+		return minimum
+	}
+	if value > maximum {
+		return maximum
+	}
+	return value
+}
+
+// Line is one configured field of the overlay before it is wrapped to the panel width.
+type Line struct {
+	Text     string
+	Title    bool
+	MaxLines int
+}
+
+func GetLines(game domain.ClientGame, config Config) []Line {
+	var lines []Line
+	for _, field := range config.Fields {
+		switch field {
+		case "name":
+			lines = append(lines, Line{Text: game.Name, Title: true, MaxLines: 2})
+		case "developers":
+			if len(game.Developers) > 0 {
+				lines = append(lines, Line{Text: strings.Join(game.Developers, ", "), MaxLines: 1})
+			}
+		case "description":
+			if game.Description != "" {
+				lines = append(lines, Line{Text: html.UnescapeString(game.Description), MaxLines: config.DescriptionLines})
+			}
+		case "summary":
+			if game.Summary != "" {
+				lines = append(lines, Line{Text: game.Summary, MaxLines: config.DescriptionLines})
+			}
+		case "genres":
+			if len(game.Genres) > 0 {
+				lines = append(lines, Line{Text: strings.Join(game.Genres, ", "), MaxLines: 1})
+			}
+		case "release":
+			if !game.FirstReleaseDate.IsZero() {
+				lines = append(lines, Line{Text: "Released " + game.FirstReleaseDate.Format("January 2, 2006"), MaxLines: 1})
+			}
+		case "rating":
+			if game.AggregatedRating > 0 {
+				lines = append(lines, Line{Text: fmt.Sprintf("Rated %.0f/100", game.AggregatedRating), MaxLines: 1})
+			}
+		case "playtime":
+			lines = append(lines, Line{Text: domain.FormatPlaytime(game.PlaytimeForever), MaxLines: 1})
+		case "last-played":
+			if !game.LastPlayed.IsZero() {
+				lines = append(lines, Line{Text: "Last played " + game.LastPlayed.Format("2006-01-02"), MaxLines: 1})
+			}
+		case "last-launched":
+			if !game.LastLaunched.IsZero() {
+				lines = append(lines, Line{Text: "Last launched " + game.LastLaunched.Format("2006-01-02"), MaxLines: 1})
+			}
+		}
+	}
+	return lines
+}
+
+// WrapText breaks the text on spaces so no line is wider than maxWidth, words wider than a line are broken where the
+// line ends. When more than maxLines are needed the last line is cut and ends with an ellipsis. A maxLines of 0 or
+// less does not truncate.
+func WrapText(text string, maxWidth float32, maxLines int, measure func(string) float32) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if measure(word) > maxWidth {
+			if line != "" {
+				lines = append(lines, line)
+			}
+			pieces := breakWord(word, maxWidth, measure)
+			lines = append(lines, pieces[:len(pieces)-1]...)
+			line = pieces[len(pieces)-1]
+			continue
+		}
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line == "" || measure(candidate) <= maxWidth {
+			line = candidate
+			continue
+		}
+		lines = append(lines, line)
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	if maxLines <= 0 || len(lines) <= maxLines {
+		return lines
+	}
+	lines = lines[:maxLines]
+	lastLine := lines[maxLines-1] + "…"
+	for measure(lastLine) > maxWidth && strings.Contains(lastLine, " ") {
+		lastLine = lastLine[:strings.LastIndex(lastLine, " ")] + "…"
+	}
+	// A line of a single broken word has no space to cut at, it loses characters instead
+	for measure(lastLine) > maxWidth && len([]rune(lastLine)) > 1 {
+		lastRunes := []rune(lastLine)
+		lastLine = string(lastRunes[:len(lastRunes)-2]) + "…"
+	}
+	lines[maxLines-1] = lastLine
+	return lines
+}
+
+// breakWord cuts the word into pieces no wider than maxWidth, each at least one character long.
+func breakWord(word string, maxWidth float32, measure func(string) float32) []string {
+	var pieces []string
+	piece := []rune{}
+	for _, character := range word {
+		if len(piece) > 0 && measure(string(append(piece, character))) > maxWidth {
+			pieces = append(pieces, string(piece))
+			piece = []rune{}
+		}
+		piece = append(piece, character)
+	}
+	return append(pieces, string(piece))
+}
+
+// GetColours picks the colours of the panel from the palette of the background. The backing is the darkened most
+// common colour so it blends with the background, the text is at least the configured contrast to the backing seen
+// over the background.
+func GetColours(backgroundPalette []color.NRGBA, config Config) (color.NRGBA, color.NRGBA) {
+	beneath := color.NRGBA{A: 255}
+	if len(backgroundPalette) > 0 {
+		beneath = backgroundPalette[0]
+	}
+	// Darken the common colour so light text stays readable on most backgrounds
+	backing := color.NRGBA{
+		R: uint8(uint(beneath.R) * 2 / 3),
+		G: uint8(uint(beneath.G) * 2 / 3),
+		B: uint8(uint(beneath.B) * 2 / 3),
+		A: config.Opacity,
+	}
+	return palette.Readable(backing, beneath, backgroundPalette, config.Contrast)
+}
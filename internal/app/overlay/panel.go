@@ -0,0 +1,107 @@
+package overlay
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+	"image/color"
+)
+
+const padding = 12
+
+// Panel shows the game metadata over the cover. The text is wrapped again each time the window is resized.
+type Panel struct {
+	widget.BaseWidget
+	lines   []Line
+	config  Config
+	backing color.NRGBA
+	text    color.NRGBA
+}
+
+// NewPanel creates the panel in the colours of the background, given by its palette.
+func NewPanel(lines []Line, backgroundPalette []color.NRGBA, config Config) *Panel {
+	panel := &Panel{
+		lines:  lines,
+		config: config,
+	}
+	panel.backing, panel.text = GetColours(backgroundPalette, config)
+	panel.ExtendBaseWidget(panel)
+	return panel
+}
+
+func (panel *Panel) CreateRenderer() fyne.WidgetRenderer {
+	return &panelRenderer{
+		panel:   panel,
+		backing: canvas.NewRectangle(panel.backing),
+	}
+}
+
+type panelRenderer struct {
+	panel   *Panel
+	backing *canvas.Rectangle
+	texts   []fyne.CanvasObject
+}
+
+func (renderer *panelRenderer) Layout(size fyne.Size) {
+	config := renderer.panel.config
+	panelWidth := size.Width * config.Width
+	textWidth := panelWidth - 2*padding
+	textColour := renderer.panel.text
+
+	renderer.texts = nil
+	textHeight := float32(0)
+	for _, line := range renderer.panel.lines {
+		fontSize := config.FontSize
+		style := fyne.TextStyle{}
+		if line.Title {
+			fontSize *= 1.4
+			style.Bold = true
+		}
+		measure := func(text string) float32 {
+			return fyne.MeasureText(text, fontSize, style).Width
+		}
+		for _, wrappedLine := range WrapText(line.Text, textWidth, line.MaxLines, measure) {
+			text := canvas.NewText(wrappedLine, textColour)
+			text.TextSize = fontSize
+			text.TextStyle = style
+			text.Resize(fyne.NewSize(textWidth, text.MinSize().Height))
+			text.Move(fyne.NewPos(padding, textHeight+padding))
+			textHeight += text.MinSize().Height
+			renderer.texts = append(renderer.texts, text)
+		}
+		textHeight += fontSize / 2
+	}
+	panelHeight := textHeight + 2*padding
+
+	panelPosition := fyne.NewPos(padding, size.Height-panelHeight-padding)
+	switch config.Position {
+	case BottomRight:
+		panelPosition = fyne.NewPos(size.Width-panelWidth-padding, size.Height-panelHeight-padding)
+	case TopLeft:
+		panelPosition = fyne.NewPos(padding, padding)
+	case TopRight:
+		panelPosition = fyne.NewPos(size.Width-panelWidth-padding, padding)
+	}
+	renderer.backing.Resize(fyne.NewSize(panelWidth, panelHeight))
+	renderer.backing.Move(panelPosition)
+	for _, text := range renderer.texts {
+		text.Move(text.Position().Add(panelPosition))
+	}
+}
+
+func (renderer *panelRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+func (renderer *panelRenderer) Refresh() {
+	renderer.backing.FillColor = renderer.panel.backing
+	renderer.Layout(renderer.panel.Size())
+	canvas.Refresh(renderer.panel)
+}
+
+func (renderer *panelRenderer) Objects() []fyne.CanvasObject {
+	return append([]fyne.CanvasObject{renderer.backing}, renderer.texts...)
+}
+
+func (renderer *panelRenderer) Destroy() {
+}
@@ -0,0 +1,108 @@
+package palette
+
+import (
+	"image/color"
+	"math"
+)
+
+var (
+	black = color.NRGBA{A: 255}
+	white = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+)
+
+// Luminance is the relative luminance of the colour as defined by WCAG, 0 for black and 1 for white.
+func Luminance(colour color.NRGBA) float64 {
+	linear := func(channel uint8) float64 {
+		value := float64(channel) / 255
+		if value <= 0.03928 {
+			return value / 12.92
+		}
+		return math.Pow((value+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(colour.R) + 0.7152*linear(colour.G) + 0.0722*linear(colour.B)
+}
+
+// Contrast is the WCAG contrast ratio of the colours, from 1 for the same colour to 21 for black on white. Text is
+// readable from 4.5 on.
+func Contrast(a color.NRGBA, b color.NRGBA) float64 {
+	lighter, darker := Luminance(a), Luminance(b)
+	if darker > lighter {
+		lighter, darker = darker, lighter
+	}
+	return (lighter + 0.05) / (darker + 0.05)
+}
+
+// Blend lays the translucent colour over the opaque one beneath it and returns the colour the eye sees.
+func Blend(over color.NRGBA, beneath color.NRGBA) color.NRGBA {
+	alpha := float64(over.A) / 255
+	blend := func(top uint8, bottom uint8) uint8 {
+		return uint8(float64(top)*alpha + float64(bottom)*(1-alpha) + 0.5)
+	}
+	return color.NRGBA{R: blend(over.R, beneath.R), G: blend(over.G, beneath.G), B: blend(over.B, beneath.B), A: 255}
+}
+
+// Readable picks a text colour for the translucent backing shown over the colour beneath it: the palette colour that
+// contrasts most when it reaches the minimum contrast, black or white otherwise. When even those do not reach it the
+// backing is darkened or lightened and made opaque until the text is readable, ending in an opaque black or white backing
+// which reaches any contrast up to 21.
+func Readable(backing color.NRGBA, beneath color.NRGBA, colours []color.NRGBA, minContrast float64) (color.NRGBA, color.NRGBA) {
+	for step := 0; ; step++ {
+		seen := Blend(backing, beneath)
+		text := black
+		if Contrast(white, seen) > Contrast(black, seen) {
+			text = white
+		}
+		bestContrast := 0.0
+		for _, colour := range colours {
+			if colourContrast := Contrast(colour, seen); colourContrast >= minContrast && colourContrast > bestContrast {
+				text, bestContrast = colour, colourContrast
+			}
+		}
+		if Contrast(text, seen) >= minContrast {
+			return backing, text
+		}
+		// Black or white text on an opaque white or black backing is 21:1, so moving there always ends up readable
+		target := black
+		if text == black {
+			target = white
+		}
+		if step == 20 {
+			return target, text
+		}
+		backing = mix(backing, target, 0.2)
+		if step >= 10 {
+			backing.A = uint8(math.Min(255, float64(backing.A)+25))
+		}
+	}
+}
+
+// mix moves the colour the share of the way to the target, keeping its alpha.
+func mix(colour color.NRGBA, target color.NRGBA, share float64) color.NRGBA {
+	move := func(from uint8, to uint8) uint8 {
+		return uint8(float64(from) + (float64(to)-float64(from))*share + 0.5)
+	}
+	return color.NRGBA{R: move(colour.R, target.R), G: move(colour.G, target.G), B: move(colour.B, target.B), A: colour.A}
+}
+
+// SortKey orders colours along the colour wheel from red over green to blue, followed by the greys from light to dark
+// which have no hue to sort by.
+func SortKey(colour color.NRGBA) float64 {
+	red, green, blue := float64(colour.R)/255, float64(colour.G)/255, float64(colour.B)/255
+	maximum := math.Max(red, math.Max(green, blue))
+	minimum := math.Min(red, math.Min(green, blue))
+	lightness := (maximum + minimum) / 2
+	chroma := maximum - minimum
+	if chroma < 0.12 {
+		return 1 + (1 - lightness)
+	}
+	var hue float64
+	switch maximum {
+	case red:
+		hue = math.Mod((green-blue)/chroma+6, 6)
+	case green:
+		hue = (blue-red)/chroma + 2
+	default:
+		hue = (red-green)/chroma + 4
+	}
+	return hue / 6
+}
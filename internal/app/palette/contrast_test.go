@@ -0,0 +1,59 @@
+package palette
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestReadableReachesMinContrast(t *testing.T) {
+	grey := color.NRGBA{R: 119, G: 119, B: 119, A: 255}
+	tests := []struct {
+		name        string
+		backing     color.NRGBA
+		beneath     color.NRGBA
+		colours     []color.NRGBA
+		minContrast float64
+	}{
+		{"white on white", color.NRGBA{R: 255, G: 255, B: 255, A: 160}, white, []color.NRGBA{white}, 4.5},
+		{"black on black", color.NRGBA{A: 160}, black, []color.NRGBA{black}, 4.5},
+		{"mid grey", color.NRGBA{R: 119, G: 119, B: 119, A: 160}, grey, []color.NRGBA{grey}, 4.5},
+		{"mid grey at AAA", color.NRGBA{R: 119, G: 119, B: 119, A: 160}, grey, nil, 7},
+		{"transparent grey", color.NRGBA{R: 119, G: 119, B: 119}, grey, nil, 4.5},
+		{"black and white only", color.NRGBA{R: 119, G: 119, B: 119}, grey, nil, 21},
+		{"red beneath", color.NRGBA{A: 100}, color.NRGBA{R: 255, A: 255}, nil, 4.5},
+	}
+	for _, test := range tests {
+		backing, text := Readable(test.backing, test.beneath, test.colours, test.minContrast)
+		if contrast := Contrast(text, Blend(backing, test.beneath)); contrast < test.minContrast {
+			t.Errorf("%s: text %v on backing %v = %.2f:1, want at least %v:1", test.name, text, backing, contrast, test.minContrast)
+		}
+	}
+}
+
+func TestReadablePrefersPaletteColours(t *testing.T) {
+	yellow := color.NRGBA{R: 255, G: 220, B: 0, A: 255}
+	backing := color.NRGBA{A: 200}
+	gotBacking, text := Readable(backing, black, []color.NRGBA{{R: 40, G: 40, B: 40, A: 255}, yellow}, 4.5)
+	if text != yellow || gotBacking != backing {
+		t.Errorf("Readable = text %v on %v, want the yellow palette colour on the unchanged backing", text, gotBacking)
+	}
+}
+
+func TestSortKey(t *testing.T) {
+	// Along the colour wheel, then the greys from light to dark
+	colours := []color.NRGBA{
+		{R: 255, A: 255},
+		{R: 255, G: 255, A: 255},
+		{G: 200, A: 255},
+		{B: 255, A: 255},
+		{R: 250, G: 250, B: 250, A: 255},
+		{R: 128, G: 128, B: 128, A: 255},
+		{A: 255},
+	}
+	for index := 1; index < len(colours); index++ {
+		if SortKey(colours[index-1]) >= SortKey(colours[index]) {
+			t.Errorf("SortKey(%v) = %v, want less than SortKey(%v) = %v", colours[index-1], SortKey(colours[index-1]),
+				colours[index], SortKey(colours[index]))
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package palette
+
+import (
+	"fmt"
+	"github.com/magiconair/properties"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+const (
+	GradientBackground = "gradient"
+	SolidBackground    = "solid"
+	BlackBackground    = "black"
+)
+
+// Config is the number of colours extracted per artwork and what is shown behind the cover of games without
+// background.
+type Config struct {
+	Size       int
+	Background string
+}
+
+func LoadConfig(props properties.Properties) Config {
+	return Config{
+		Size:       props.GetInt("visualizer.palette.size", 5),
+		Background: props.GetString("visualizer.palette.background", GradientBackground),
+	}
+}
+
+// ImageLoader returns the decoded image of an artwork URL.
+type ImageLoader interface {
+	GetImage(imageUrl string) (image.Image, error)
+}
+
+// AddPalette extracts the palette of the artwork when it has none yet, so it is stored with the artwork and only
+// computed once.
+func AddPalette(artwork *domain.GameArtwork, loader ImageLoader, size int) error {
+	if len(artwork.Palette) > 0 {
+		return nil
+	}
+	img, loadErr := loader.GetImage(artwork.Url())
+	if loadErr != nil {
+		return loadErr
+	}
+	artwork.Palette = Format(Extract(img, size))
+	return nil
+}
+
+// Of returns the stored palette of the artwork, or extracts it from the image of the artwork when none is stored, as
+// for games synced before palettes were.
+func Of(artwork domain.GameArtwork, img image.Image, size int) []color.NRGBA {
+	colours := Parse(artwork.Palette)
+	if len(colours) > 0 || img == nil {
+		return colours
+	}
+	return Extract(img, size)
+}
+
+// Extract finds the dominant colours of the image by k-means clustering of its pixels, the most common colour first.
+// Images with few colours return fewer than size colours.
+func Extract(img image.Image, size int) []color.NRGBA {
+	samples := sample(img)
+	if len(samples) == 0 || size < 1 {
+		return nil
+	}
+	// A fixed seed gives the same palette for the same image on every sync
+	random := rand.New(rand.NewSource(1))
+	centroids := seedCentroids(samples, size, random)
+	assignments := make([]int, len(samples))
+	for iteration := 0; iteration < 10; iteration++ {
+		changed := false
+		for sampleIndex, pixel := range samples {
+			nearest := nearestCentroid(pixel, centroids)
+			if nearest != assignments[sampleIndex] || iteration == 0 {
+				changed = true
+			}
+			assignments[sampleIndex] = nearest
+		}
+		if !changed {
+			break
+		}
+		sums := make([][3]float64, len(centroids))
+		counts := make([]int, len(centroids))
+		for sampleIndex, pixel := range samples {
+			cluster := assignments[sampleIndex]
+			for channel := 0; channel < 3; channel++ {
+				sums[cluster][channel] += pixel[channel]
+			}
+			counts[cluster]++
+		}
+		for cluster := range centroids {
+			if counts[cluster] > 0 {
+				for channel := 0; channel < 3; channel++ {
+					centroids[cluster][channel] = sums[cluster][channel] / float64(counts[cluster])
+				}
+			}
+		}
+	}
+
+	counts := make([]int, len(centroids))
+	for _, cluster := range assignments {
+		counts[cluster]++
+	}
+	var clusters []int
+	for cluster := range centroids {
+		if counts[cluster] > 0 {
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return counts[clusters[i]] > counts[clusters[j]]
+	})
+	var colours []color.NRGBA
+	for _, cluster := range clusters {
+		centroid := centroids[cluster]
+		colours = append(colours, color.NRGBA{R: uint8(centroid[0] + 0.5), G: uint8(centroid[1] + 0.5), B: uint8(centroid[2] + 0.5), A: 255})
+	}
+	return colours
+}
+
+// sample reads a grid of at most 64x64 pixels of the image, leaving out the transparent ones of logos and icons.
+func sample(img image.Image) [][3]float64 {
+	bounds := img.Bounds()
+	stepX := bounds.Dx()/64 + 1
+	stepY := bounds.Dy()/64 + 1
+	var samples [][3]float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			pixel := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if pixel.A < 128 {
+				continue
+			}
+			samples = append(samples, [3]float64{float64(pixel.R), float64(pixel.G), float64(pixel.B)})
+		}
+	}
+	return samples
+}
+
+// seedCentroids picks the first centroids k-means++ style, each far from the ones picked before, so small but distinct
+// colours get a cluster of their own.
+func seedCentroids(samples [][3]float64, size int, random *rand.Rand) [][3]float64 {
+	centroids := [][3]float64{samples[random.Intn(len(samples))]}
+	distances := make([]float64, len(samples))
+	for len(centroids) < size {
+		total := 0.0
+		for sampleIndex, pixel := range samples {
+			distances[sampleIndex] = distance(pixel, centroids[nearestCentroid(pixel, centroids)])
+			total += distances[sampleIndex]
+		}
+		if total == 0 {
+			break
+		}
+		target := random.Float64() * total
+		for sampleIndex, sampleDistance := range distances {
+			target -= sampleDistance
+			if target <= 0 {
+				centroids = append(centroids, samples[sampleIndex])
+				break
+			}
+		}
+	}
+	return centroids
+}
+
+func nearestCentroid(pixel [3]float64, centroids [][3]float64) int {
+	nearest := 0
+	nearestDistance := math.MaxFloat64
+	for cluster, centroid := range centroids {
+		if centroidDistance := distance(pixel, centroid); centroidDistance < nearestDistance {
+			nearest, nearestDistance = cluster, centroidDistance
+		}
+	}
+	return nearest
+}
+
+func distance(a [3]float64, b [3]float64) float64 {
+	red, green, blue := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return red*red + green*green + blue*blue
+}
+
+// Background is shown behind the cover of a game without background: a diagonal gradient from the most common colour
+// of the cover to the second one, or the most common colour alone. The colours are darkened so the cover stands out.
+// Nil means black, as are covers without palette.
+func Background(colours []color.NRGBA, style string, width int, height int) image.Image {
+	if len(colours) == 0 || width <= 0 || height <= 0 || (style != GradientBackground && style != SolidBackground) {
+		return nil
+	}
+	from := mix(colours[0], black, 0.4)
+	to := from
+	if style == GradientBackground && len(colours) > 1 {
+		to = mix(colours[1], black, 0.4)
+	}
+	background := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			share := (float64(x)/float64(width) + float64(y)/float64(height)) / 2
+			background.SetNRGBA(x, y, mix(from, to, share))
+		}
+	}
+	return background
+}
+
+// Format writes the colours as hex RGB like #1a2b3c.
+func Format(colours []color.NRGBA) []string {
+	var hexColours []string
+	for _, colour := range colours {
+		hexColours = append(hexColours, fmt.Sprintf("#%02x%02x%02x", colour.R, colour.G, colour.B))
+	}
+	return hexColours
+}
+
+// Parse reads colours written by Format, leaving out invalid ones.
+func Parse(hexColours []string) []color.NRGBA {
+	var colours []color.NRGBA
+	for _, hexColour := range hexColours {
+		var red, green, blue uint8
+		_, scanErr := fmt.Sscanf(strings.TrimPrefix(hexColour, "#"), "%02x%02x%02x", &red, &green, &blue)
+		if scanErr == nil {
+			colours = append(colours, color.NRGBA{R: red, G: green, B: blue, A: 255})
+		}
+	}
+	return colours
+}
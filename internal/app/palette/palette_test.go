@@ -0,0 +1,61 @@
+package palette
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+// newStripes returns an image of vertical stripes, each colour as wide as its width.
+func newStripes(colours []color.NRGBA, widths []int) image.Image {
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, total, 40))
+	x := 0
+	for stripe, colour := range colours {
+		for end := x + widths[stripe]; x < end; x++ {
+			for y := 0; y < 40; y++ {
+				img.SetNRGBA(x, y, colour)
+			}
+		}
+	}
+	return img
+}
+
+func TestExtract(t *testing.T) {
+	red := color.NRGBA{R: 200, G: 20, B: 20, A: 255}
+	blue := color.NRGBA{R: 20, G: 20, B: 200, A: 255}
+	green := color.NRGBA{R: 20, G: 200, B: 20, A: 255}
+	img := newStripes([]color.NRGBA{red, blue, green}, []int{60, 30, 10})
+
+	colours := Extract(img, 3)
+	if want := []color.NRGBA{red, blue, green}; !reflect.DeepEqual(colours, want) {
+		t.Errorf("Extract = %v, want %v, the most common first", colours, want)
+	}
+	// The same image gives the same palette on every sync
+	for run := 0; run < 5; run++ {
+		if again := Extract(img, 3); !reflect.DeepEqual(again, colours) {
+			t.Fatalf("Extract run %d = %v, want %v as the first run", run, again, colours)
+		}
+	}
+	if colours := Extract(newStripes([]color.NRGBA{red}, []int{10}), 5); !reflect.DeepEqual(colours, []color.NRGBA{red}) {
+		t.Errorf("Extract of one colour = %v, want the colour alone", colours)
+	}
+	if colours := Extract(image.NewNRGBA(image.Rect(0, 0, 10, 10)), 5); colours != nil {
+		t.Errorf("Extract of a transparent image = %v, want none", colours)
+	}
+}
+
+func TestFormatAndParse(t *testing.T) {
+	colours := []color.NRGBA{{R: 26, G: 43, B: 60, A: 255}, {R: 255, A: 255}}
+	hexColours := Format(colours)
+	if want := []string{"#1a2b3c", "#ff0000"}; !reflect.DeepEqual(hexColours, want) {
+		t.Errorf("Format = %v, want %v", hexColours, want)
+	}
+	if parsed := Parse(append(hexColours, "red", "#12")); !reflect.DeepEqual(parsed, colours) {
+		t.Errorf("Parse = %v, want %v without the invalid colours", parsed, colours)
+	}
+}
@@ -0,0 +1,158 @@
+package remote
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/magiconair/properties"
+	"net/http"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/slideshow"
+)
+
+//go:embed remote.html
+var remotePage []byte
+
+// Config is the address of the remote control and the token every request must carry, either as bearer token or as
+// token parameter since browsers cannot set headers on event streams.
+type Config struct {
+	Enabled bool
+	Address string
+	Token   string
+}
+
+// LoadConfig reads the token from the secret properties and the rest from the main properties.
+func LoadConfig(props properties.Properties, secretProps properties.Properties) Config {
+	return Config{
+		Enabled: props.GetBool("visualizer.remote.enabled", false),
+		Address: props.GetString("visualizer.remote.address", ":8081"),
+		Token:   secretProps.GetString("remote.token", ""),
+	}
+}
+
+// Server is the remote control of a running slideshow: commands as POST requests, the current game and a live event
+// stream of what the slideshow shows.
+type Server struct {
+	config     Config
+	controller *slideshow.Controller
+}
+
+func New(config Config, controller *slideshow.Controller) *Server {
+	return &Server{config: config, controller: controller}
+}
+
+func (server *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", server.handlePage)
+	mux.HandleFunc("/next", server.handleCommand(slideshow.Next))
+	mux.HandleFunc("/previous", server.handleCommand(slideshow.Previous))
+	mux.HandleFunc("/pause", server.handleCommand(slideshow.Pause))
+	mux.HandleFunc("/resume", server.handleCommand(slideshow.Resume))
+	mux.HandleFunc("/toggle-pause", server.handleCommand(slideshow.TogglePause))
+	mux.HandleFunc("/show", server.handleCommand(slideshow.ShowGame))
+	mux.HandleFunc("/now", server.authorized(server.handleNow))
+	mux.HandleFunc("/events", server.authorized(server.handleEvents))
+	return mux
+}
+
+// ListenAndServe serves until the server fails. It refuses to start without token, anyone on the network could
+// control the slideshow otherwise.
+func (server *Server) ListenAndServe() error {
+	if server.config.Token == "" {
+		return errors.New("No remote control token configured in remote.token")
+	}
+	return http.ListenAndServe(server.config.Address, server.Handler())
+}
+
+func (server *Server) authorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		token := request.URL.Query().Get("token")
+		if authorization := request.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+			token = strings.TrimPrefix(authorization, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(server.config.Token)) != 1 {
+			http.Error(writer, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+		handler(writer, request)
+	}
+}
+
+// handlePage serves the remote control page for phones. The page itself holds no secrets, its requests need the token.
+func (server *Server) handlePage(writer http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != "/" {
+		http.NotFound(writer, request)
+		return
+	}
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Write(remotePage)
+}
+
+// handleCommand sends the command to the slideshow. ShowGame takes the game from the source and id parameters, e.g.
+// /show?source=steam&id=620.
+func (server *Server) handleCommand(kind slideshow.CommandKind) http.HandlerFunc {
+	return server.authorized(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost {
+			http.Error(writer, "Method "+request.Method+" not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		command := slideshow.Command{Kind: kind}
+		if kind == slideshow.ShowGame {
+			source := domain.ParseGameSource(request.FormValue("source"))
+			if source == domain.UnknownGameSource || request.FormValue("id") == "" {
+				http.Error(writer, "Parameters source and id must name a game", http.StatusBadRequest)
+				return
+			}
+			command.GameKey = domain.ClientGame{Source: source, SourceId: request.FormValue("id")}.Key()
+		}
+		server.controller.Send(command)
+		writer.WriteHeader(http.StatusAccepted)
+	})
+}
+
+func (server *Server) handleNow(writer http.ResponseWriter, request *http.Request) {
+	event, shown := server.controller.Current()
+	if !shown {
+		http.Error(writer, "Nothing shown yet", http.StatusNotFound)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(event)
+}
+
+// handleEvents streams the events of the slideshow as server-sent events, starting with what it shows right now.
+func (server *Server) handleEvents(writer http.ResponseWriter, request *http.Request) {
+	flusher, canFlush := writer.(http.Flusher)
+	if !canFlush {
+		http.Error(writer, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	events, cancel := server.controller.Subscribe()
+	defer cancel()
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	if event, shown := server.controller.Current(); shown {
+		writeEvent(writer, event)
+	}
+	flusher.Flush()
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case event, open := <-events:
+			if !open {
+				return
+			}
+			writeEvent(writer, event)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(writer http.ResponseWriter, event slideshow.Event) {
+	eventJson, _ := json.Marshal(event)
+	fmt.Fprintf(writer, "event: showing\ndata: %s\n\n", eventJson)
+}
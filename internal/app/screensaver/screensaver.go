@@ -0,0 +1,123 @@
+package screensaver
+
+import (
+	"errors"
+	"github.com/magiconair/properties"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ExitOnInput  = "exit"
+	PauseOnInput = "pause"
+)
+
+// Config is what the screensaver does on input and when it starts by itself. An idle delay of 0 leaves starting it to
+// the desktop's screensaver or the user.
+type Config struct {
+	OnInput      string
+	IdleDelay    time.Duration
+	IdleCommand  string
+	PollInterval time.Duration
+}
+
+func LoadConfig(props properties.Properties) Config {
+	return Config{
+		OnInput:      props.GetString("visualizer.screensaver.input", ExitOnInput),
+		IdleDelay:    time.Minute * time.Duration(props.GetInt("visualizer.screensaver.idle.minutes", 0)),
+		IdleCommand:  props.GetString("visualizer.screensaver.idle.command", "xprintidle"),
+		PollInterval: time.Second * time.Duration(props.GetInt("visualizer.screensaver.idle.poll.seconds", 5)),
+	}
+}
+
+// IdleProvider tells for how long the user has not used keyboard or mouse.
+type IdleProvider interface {
+	IdleTime() (time.Duration, error)
+}
+
+// CommandIdleProvider reads the idle time from a command printing it in milliseconds, like xprintidle on X11.
+type CommandIdleProvider struct {
+	Command string
+}
+
+func (provider CommandIdleProvider) IdleTime() (time.Duration, error) {
+	args := strings.Fields(provider.Command)
+	if len(args) == 0 {
+		return 0, errors.New("No idle command configured in visualizer.screensaver.idle.command")
+	}
+	output, runErr := exec.Command(args[0], args[1:]...).Output()
+	if runErr != nil {
+		return 0, errors.New("Failed to run " + provider.Command + ": " + runErr.Error())
+	}
+	milliseconds, parseErr := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if parseErr != nil {
+		return 0, errors.New(provider.Command + " did not print the idle milliseconds: " + parseErr.Error())
+	}
+	return time.Duration(milliseconds) * time.Millisecond, nil
+}
+
+// Trigger tells when the screensaver is due: when the user has been idle for the delay. Once due it is only due again
+// after the user came back in between, so a screensaver closed without input is not started again right away.
+type Trigger struct {
+	provider IdleProvider
+	delay    time.Duration
+	armed    bool
+}
+
+func NewTrigger(provider IdleProvider, delay time.Duration) *Trigger {
+	return &Trigger{provider: provider, delay: delay, armed: true}
+}
+
+// Due reads the idle time and tells if the screensaver is to be started now.
+func (trigger *Trigger) Due() (bool, error) {
+	idleTime, idleErr := trigger.provider.IdleTime()
+	if idleErr != nil {
+		return false, idleErr
+	}
+	if idleTime < trigger.delay {
+		trigger.armed = true
+		return false, nil
+	}
+	if !trigger.armed {
+		return false, nil
+	}
+	trigger.armed = false
+	return true, nil
+}
+
+// Wait polls the idle time every interval until the screensaver is due.
+func (trigger *Trigger) Wait(pollInterval time.Duration) error {
+	for {
+		due, dueErr := trigger.Due()
+		if dueErr != nil || due {
+			return dueErr
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// MotionFilter tells real mouse movements from the jitter of a mouse lying on the desk and from the position the
+// pointer has when the screensaver opens.
+type MotionFilter struct {
+	threshold float32
+	originSet bool
+	originX   float32
+	originY   float32
+}
+
+func NewMotionFilter(threshold float32) *MotionFilter {
+	return &MotionFilter{threshold: threshold}
+}
+
+// Moved records the pointer position and tells if it moved farther than the threshold from the first position seen.
+func (filter *MotionFilter) Moved(x float32, y float32) bool {
+	if !filter.originSet {
+		filter.originX, filter.originY = x, y
+		filter.originSet = true
+		return false
+	}
+	deltaX, deltaY := x-filter.originX, y-filter.originY
+	return deltaX*deltaX+deltaY*deltaY > filter.threshold*filter.threshold
+}
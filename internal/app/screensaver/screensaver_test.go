@@ -0,0 +1,95 @@
+package screensaver
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeIdleProvider returns the idle times in turn.
+type fakeIdleProvider struct {
+	idleTimes []time.Duration
+	err       error
+}
+
+func (provider *fakeIdleProvider) IdleTime() (time.Duration, error) {
+	if provider.err != nil {
+		return 0, provider.err
+	}
+	idleTime := provider.idleTimes[0]
+	provider.idleTimes = provider.idleTimes[1:]
+	return idleTime, nil
+}
+
+func TestTriggerDue(t *testing.T) {
+	tests := []struct {
+		name      string
+		idleTimes []time.Duration
+		due       []bool
+	}{
+		{"active user", []time.Duration{0, time.Minute, 9 * time.Minute}, []bool{false, false, false}},
+		{"idle for the delay", []time.Duration{9 * time.Minute, 10 * time.Minute}, []bool{false, true}},
+		{"idle from the start", []time.Duration{time.Hour}, []bool{true}},
+		{"due once while idle", []time.Duration{10 * time.Minute, 11 * time.Minute, 20 * time.Minute}, []bool{true, false, false}},
+		{"due again after input", []time.Duration{10 * time.Minute, 11 * time.Minute, time.Second, 10 * time.Minute}, []bool{true, false, false, true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trigger := NewTrigger(&fakeIdleProvider{idleTimes: test.idleTimes}, 10*time.Minute)
+			for index, wantDue := range test.due {
+				due, dueErr := trigger.Due()
+				if dueErr != nil {
+					t.Fatalf("Due failed: %v", dueErr)
+				}
+				if due != wantDue {
+					t.Errorf("Due at idle time %v = %v, want %v", test.idleTimes[index], due, wantDue)
+				}
+			}
+		})
+	}
+}
+
+func TestTriggerErrors(t *testing.T) {
+	trigger := NewTrigger(&fakeIdleProvider{err: errors.New("no display")}, time.Minute)
+	if due, dueErr := trigger.Due(); dueErr == nil || due {
+		t.Errorf("Due with a failing provider = %v %v, want not due and the error", due, dueErr)
+	}
+	if waitErr := trigger.Wait(time.Millisecond); waitErr == nil {
+		t.Error("Wait with a failing provider succeeded, want the error")
+	}
+}
+
+func TestTriggerWait(t *testing.T) {
+	provider := &fakeIdleProvider{idleTimes: []time.Duration{0, time.Second, time.Minute}}
+	if waitErr := NewTrigger(provider, time.Minute).Wait(time.Millisecond); waitErr != nil {
+		t.Fatalf("Wait failed: %v", waitErr)
+	}
+	if len(provider.idleTimes) != 0 {
+		t.Errorf("Wait returned with %d idle times left, want it to poll until due", len(provider.idleTimes))
+	}
+}
+
+func TestMotionFilter(t *testing.T) {
+	type position struct{ x, y float32 }
+	tests := []struct {
+		name      string
+		positions []position
+		moved     []bool
+	}{
+		{"first position", []position{{500, 300}}, []bool{false}},
+		{"jitter", []position{{500, 300}, {503, 304}, {497, 296}, {500, 310}}, []bool{false, false, false, false}},
+		{"moved away", []position{{500, 300}, {511, 300}}, []bool{false, true}},
+		{"moved diagonally", []position{{500, 300}, {508, 308}}, []bool{false, true}},
+		{"creeping stays near the origin", []position{{500, 300}, {505, 300}, {509, 300}, {511, 300}}, []bool{false, false, false, true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter := NewMotionFilter(10)
+			for index, pointer := range test.positions {
+				if moved := filter.Moved(pointer.x, pointer.y); moved != test.moved[index] {
+					t.Errorf("Moved to %v = %v, want %v", pointer, moved, test.moved[index])
+				}
+			}
+		})
+	}
+}
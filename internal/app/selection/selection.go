@@ -0,0 +1,259 @@
+package selection
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+// Strategy picks the index of the next game to show from the game list, skipping the games of the excluded keys, e.g.
+// the games other displays show. It returns -1 when no game is left. Strategies are safe to use from several
+// goroutines, like the slideshows of the browser and the wall.
+type Strategy interface {
+	Next(games []domain.ClientGame, excludedKeys map[string]bool) int
+}
+
+// StateStore keeps the state of a strategy between restarts of the visualizer.
+type StateStore interface {
+	GetState(key string) (string, error)
+	SetState(key string, value string) error
+}
+
+// prefixedStore keeps the state under keys of their own, for strategies sharing a store with others.
+type prefixedStore struct {
+	store  StateStore
+	prefix string
+}
+
+// WithPrefix returns the store keeping the state of a strategy under keys starting with the prefix, so the strategy
+// does not take over the state of another one using the store, e.g. the server's slideshow next to the visualizer.
+func WithPrefix(store StateStore, prefix string) StateStore {
+	return prefixedStore{store: store, prefix: prefix}
+}
+
+func (store prefixedStore) GetState(key string) (string, error) {
+	return store.store.GetState(store.prefix + key)
+}
+
+func (store prefixedStore) SetState(key string, value string) error {
+	return store.store.SetState(store.prefix+key, value)
+}
+
+// New returns the strategy configured by name. Unknown names fall back to plain random selection.
+func New(name string, recencySize int, store StateStore) Strategy {
+	if recencySize < 0 {
+		recencySize = 0
+	}
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "shuffle":
+		return &shuffleBag{store: store}
+	case "playtime":
+		return weighted{weight: playtimeWeight}
+	case "backlog":
+		return weighted{weight: backlogWeight}
+	case "recency":
+		return &recencyPenalised{size: recencySize, store: store}
+	}
+	return random{}
+}
+
+type random struct{}
+
+func (random) Next(games []domain.ClientGame, excludedKeys map[string]bool) int {
+	candidates := getCandidates(games, excludedKeys)
+	if len(candidates) == 0 {
+		return -1
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+// shuffleBag shows every game once per cycle. The keys left in the bag are stored so a restart continues the cycle.
+// Excluded games stay in the bag until they can be shown.
+type shuffleBag struct {
+	mutex sync.Mutex
+	store StateStore
+	bag   []string
+}
+
+const shuffleBagStateKey = "shuffle-bag"
+
+func (strategy *shuffleBag) Next(games []domain.ClientGame, excludedKeys map[string]bool) int {
+	if len(games) == 0 {
+		return -1
+	}
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+	if strategy.bag == nil {
+		strategy.bag = loadKeys(strategy.store, shuffleBagStateKey)
+	}
+	gameIndexes := getGameIndexes(games)
+
+	// Keys of games no longer in the list are dropped. Once only excluded games are left the bag gets the next cycle of
+	// the other games behind them.
+	for refilled := false; ; refilled = true {
+		pickedIndex := -1
+		keptKeys := make([]string, 0, len(strategy.bag))
+		for _, key := range strategy.bag {
+			gameIndex, found := gameIndexes[key]
+			if !found {
+				continue
+			}
+			if pickedIndex < 0 && !excludedKeys[key] {
+				pickedIndex = gameIndex
+				continue
+			}
+			keptKeys = append(keptKeys, key)
+		}
+		strategy.bag = keptKeys
+		if pickedIndex >= 0 || refilled {
+			saveKeys(strategy.store, shuffleBagStateKey, strategy.bag)
+			return pickedIndex
+		}
+		bagKeys := make(map[string]bool)
+		for _, key := range strategy.bag {
+			bagKeys[key] = true
+		}
+		for _, gameIndex := range rand.Perm(len(games)) {
+			if key := games[gameIndex].Key(); !bagKeys[key] {
+				strategy.bag = append(strategy.bag, key)
+			}
+		}
+	}
+}
+
+// weighted picks games at random with a chance proportional to their weight.
+type weighted struct {
+	weight func(game domain.ClientGame) float64
+}
+
+func (strategy weighted) Next(games []domain.ClientGame, excludedKeys map[string]bool) int {
+	candidates := getCandidates(games, excludedKeys)
+	if len(candidates) == 0 {
+		return -1
+	}
+	weights := make([]float64, len(candidates))
+	for candidate, gameIndex := range candidates {
+		weights[candidate] = strategy.weight(games[gameIndex])
+	}
+	return candidates[pickWeighted(weights)]
+}
+
+// playtimeWeight favours the games played the most. The square root keeps a few very long played games from taking
+// over the slideshow.
+func playtimeWeight(game domain.ClientGame) float64 {
+	return 1 + math.Sqrt(float64(game.PlaytimeForever)/60)
+}
+
+// backlogWeight favours the games that were never or barely played.
+func backlogWeight(game domain.ClientGame) float64 {
+	return 1 / (1 + float64(game.PlaytimeForever)/60)
+}
+
+// recencyPenalised is random selection where recently shown games are less likely to come up again. The penalty
+// fades the longer ago the game was shown.
+type recencyPenalised struct {
+	mutex  sync.Mutex
+	size   int
+	store  StateStore
+	recent []string
+}
+
+const recencyStateKey = "recent-games"
+
+func (strategy *recencyPenalised) Next(games []domain.ClientGame, excludedKeys map[string]bool) int {
+	candidates := getCandidates(games, excludedKeys)
+	if len(candidates) == 0 {
+		return -1
+	}
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+	if strategy.recent == nil {
+		strategy.recent = loadKeys(strategy.store, recencyStateKey)
+	}
+	// The most recently shown game is last
+	recentPositions := make(map[string]int)
+	for position, key := range strategy.recent {
+		recentPositions[key] = position
+	}
+
+	weights := make([]float64, len(candidates))
+	for candidate, gameIndex := range candidates {
+		weights[candidate] = 1
+		if position, found := recentPositions[games[gameIndex].Key()]; found {
+			weights[candidate] = float64(len(strategy.recent)-position-1) / float64(len(strategy.recent)+1)
+		}
+	}
+	gameIndex := candidates[pickWeighted(weights)]
+
+	strategy.recent = append(strategy.recent, games[gameIndex].Key())
+	if len(strategy.recent) > strategy.size {
+		strategy.recent = strategy.recent[len(strategy.recent)-strategy.size:]
+	}
+	saveKeys(strategy.store, recencyStateKey, strategy.recent)
+	return gameIndex
+}
+
+func pickWeighted(weights []float64) int {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	if total <= 0 {
+		return rand.Intn(len(weights))
+	}
+	pick := rand.Float64() * total
+	for index, weight := range weights {
+		pick -= weight
+		if pick < 0 {
+			return index
+		}
+	}
+	return len(weights) - 1
+}
+
+// getCandidates returns the indexes of the games not excluded.
+func getCandidates(games []domain.ClientGame, excludedKeys map[string]bool) []int {
+	candidates := make([]int, 0, len(games))
+	for gameIndex, game := range games {
+		if !excludedKeys[game.Key()] {
+			candidates = append(candidates, gameIndex)
+		}
+	}
+	return candidates
+}
+
+func getGameIndexes(games []domain.ClientGame) map[string]int {
+	gameIndexes := make(map[string]int)
+	for gameIndex, game := range games {
+		gameIndexes[game.Key()] = gameIndex
+	}
+	return gameIndexes
+}
+
+// loadKeys returns an empty list when nothing is stored yet or the state cannot be read, the strategy then simply
+// starts over.
+func loadKeys(store StateStore, stateKey string) []string {
+	keys := make([]string, 0)
+	if store == nil {
+		return keys
+	}
+	value, getErr := store.GetState(stateKey)
+	if getErr != nil {
+		return keys
+	}
+	json.Unmarshal([]byte(value), &keys)
+	return keys
+}
+
+func saveKeys(store StateStore, stateKey string, keys []string) {
+	if store == nil {
+		return
+	}
+	bytes, marshErr := json.Marshal(keys)
+	if marshErr == nil {
+		store.SetState(stateKey, string(bytes))
+	}
+}
@@ -0,0 +1,189 @@
+package selection
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+// fakeStore keeps the state in memory like the game store keeps it in the DB.
+type fakeStore map[string]string
+
+func (store fakeStore) GetState(key string) (string, error) {
+	value, found := store[key]
+	if !found {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func (store fakeStore) SetState(key string, value string) error {
+	store[key] = value
+	return nil
+}
+
+func newGames(count int) []domain.ClientGame {
+	games := make([]domain.ClientGame, count)
+	for index := range games {
+		games[index] = domain.ClientGame{Source: domain.Steam, SourceId: strconv.Itoa(index + 1)}
+	}
+	return games
+}
+
+func TestEmptyGames(t *testing.T) {
+	for _, name := range []string{"random", "shuffle", "playtime", "backlog", "recency"} {
+		if gameIndex := New(name, 5, fakeStore{}).Next(nil, nil); gameIndex != -1 {
+			t.Errorf("%s Next of no games = %d, want -1", name, gameIndex)
+		}
+	}
+}
+
+func TestShuffleBagShowsEveryGameOncePerCycle(t *testing.T) {
+	games := newGames(7)
+	strategy := New("shuffle", 0, nil)
+	for cycle := 0; cycle < 3; cycle++ {
+		shown := make(map[int]bool)
+		for pick := 0; pick < len(games); pick++ {
+			gameIndex := strategy.Next(games, nil)
+			if shown[gameIndex] {
+				t.Fatalf("cycle %d showed game %d twice", cycle, gameIndex)
+			}
+			shown[gameIndex] = true
+		}
+	}
+}
+
+func TestShuffleBagKeepsExcludedGames(t *testing.T) {
+	games := newGames(5)
+	strategy := New("shuffle", 0, nil)
+	// The game shown on another display is skipped for the rest of the cycle, then still comes up in it
+	excludedKeys := map[string]bool{games[0].Key(): true}
+	shown := make(map[int]bool)
+	for pick := 1; pick < len(games); pick++ {
+		gameIndex := strategy.Next(games, excludedKeys)
+		if gameIndex == 0 || shown[gameIndex] {
+			t.Fatalf("pick %d showed game %d, want a game not excluded nor shown", pick, gameIndex)
+		}
+		shown[gameIndex] = true
+	}
+	if gameIndex := strategy.Next(games, nil); gameIndex != 0 {
+		t.Errorf("last pick of the cycle = %d, want the excluded game 0 kept in the bag", gameIndex)
+	}
+
+	allKeys := make(map[string]bool)
+	for _, game := range games {
+		allKeys[game.Key()] = true
+	}
+	for _, name := range []string{"random", "shuffle", "playtime", "backlog", "recency"} {
+		if gameIndex := New(name, 5, fakeStore{}).Next(games, allKeys); gameIndex != -1 {
+			t.Errorf("%s Next with all games excluded = %d, want -1", name, gameIndex)
+		}
+	}
+}
+
+func TestShuffleBagContinuesAfterRestart(t *testing.T) {
+	games := newGames(6)
+	store := fakeStore{}
+	shown := make(map[int]bool)
+	for pick := 0; pick < 2; pick++ {
+		shown[New("shuffle", 0, store).Next(games, nil)] = true
+	}
+	// Every pick by a new strategy, as after a restart, continues the cycle from the stored bag
+	for pick := 2; pick < len(games); pick++ {
+		gameIndex := New("shuffle", 0, store).Next(games, nil)
+		if shown[gameIndex] {
+			t.Fatalf("pick %d after restart showed game %d again, want the rest of the cycle", pick, gameIndex)
+		}
+		shown[gameIndex] = true
+	}
+}
+
+func TestShuffleBagDropsRemovedGames(t *testing.T) {
+	store := fakeStore{}
+	New("shuffle", 0, store).Next(newGames(5), nil)
+	remaining := newGames(2)
+	for pick := 0; pick < 10; pick++ {
+		if gameIndex := New("shuffle", 0, store).Next(remaining, nil); gameIndex < 0 || gameIndex >= len(remaining) {
+			t.Fatalf("Next after games were removed = %d, want an index of the %d games left", gameIndex, len(remaining))
+		}
+	}
+}
+
+func TestRecencyPenalty(t *testing.T) {
+	games := newGames(2)
+	store := fakeStore{}
+	strategy := New("recency", 1, store)
+	last := strategy.Next(games, nil)
+	// The most recently shown game has no chance to come up right again
+	for pick := 0; pick < 20; pick++ {
+		gameIndex := strategy.Next(games, nil)
+		if gameIndex == last {
+			t.Fatalf("pick %d showed game %d again right after it", pick, gameIndex)
+		}
+		last = gameIndex
+	}
+	if gameIndex := New("recency", 1, store).Next(games, nil); gameIndex == last {
+		t.Errorf("pick after restart showed game %d again, want the stored recent games penalised", gameIndex)
+	}
+}
+
+func TestWeights(t *testing.T) {
+	tests := []struct {
+		playtime       int
+		playtimeWeight float64
+		backlogWeight  float64
+	}{
+		{0, 1, 1},
+		{60, 2, 0.5},
+		{540, 4, 0.1},
+		{6000, 11, 1.0 / 101},
+	}
+	for _, test := range tests {
+		game := domain.ClientGame{PlaytimeForever: test.playtime}
+		if got := playtimeWeight(game); got != test.playtimeWeight {
+			t.Errorf("playtimeWeight of %d minutes = %v, want %v", test.playtime, got, test.playtimeWeight)
+		}
+		if got := backlogWeight(game); got != test.backlogWeight {
+			t.Errorf("backlogWeight of %d minutes = %v, want %v", test.playtime, got, test.backlogWeight)
+		}
+	}
+}
+
+func TestWeightedPicksByWeight(t *testing.T) {
+	games := []domain.ClientGame{{SourceId: "played", PlaytimeForever: 60000}, {SourceId: "new"}}
+	counts := map[string]map[int]int{"playtime": {}, "backlog": {}}
+	for name, count := range counts {
+		strategy := New(name, 0, nil)
+		for pick := 0; pick < 2000; pick++ {
+			count[strategy.Next(games, nil)]++
+		}
+	}
+	// Weights of 1+sqrt(1000) against 1: the played game comes up about 97% of the time with playtime
+	if played := counts["playtime"][0]; played < 1800 {
+		t.Errorf("playtime picked the played game %d of 2000 times, want most of them", played)
+	}
+	if played := counts["backlog"][0]; played > 200 {
+		t.Errorf("backlog picked the played game %d of 2000 times, want few of them", played)
+	}
+	if got := pickWeighted([]float64{0, 0, 0}); got < 0 || got > 2 {
+		t.Errorf("pickWeighted of zero weights = %d, want any index", got)
+	}
+}
+
+func TestPrefixedStateIsSeparate(t *testing.T) {
+	games := newGames(4)
+	store := fakeStore{}
+	// Both show every game of their cycle, neither takes games from the other's bag
+	visualizerShown, serverShown := make(map[int]bool), make(map[int]bool)
+	for pick := 0; pick < len(games); pick++ {
+		visualizerShown[New("shuffle", 0, store).Next(games, nil)] = true
+		serverShown[New("shuffle", 0, WithPrefix(store, "serve:")).Next(games, nil)] = true
+	}
+	if len(visualizerShown) != len(games) || len(serverShown) != len(games) {
+		t.Errorf("visualizer showed %d and server %d of %d games, want all games each", len(visualizerShown), len(serverShown), len(games))
+	}
+	if _, found := store["serve:"+shuffleBagStateKey]; !found {
+		t.Errorf("state keys = %v, want the server's bag under serve:", store)
+	}
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"github.com/tidwall/buntdb"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/filter"
+	"vg-cover-screen-saver-go/internal/app/store"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// Syncer does the library changes that need the API clients of the game sources and artwork providers.
+type Syncer interface {
+	// SyncGames stores the newly owned games and refreshes the stored ones.
+	SyncGames() error
+	// MatchIgdbGame returns the game with the metadata and artworks of the IGDB game with the id in place of the ones
+	// of the game IGDB matched by name.
+	MatchIgdbGame(game domain.ClientGame, igdbId int) (domain.ClientGame, error)
+}
+
+// syncStatus tells API clients if a sync triggered by POST /sync still runs and how the last one ended.
+type syncStatus struct {
+	mutex        sync.Mutex
+	Running      bool       `json:"running"`
+	LastFinished *time.Time `json:"last-finished,omitempty"`
+	LastError    string     `json:"last-error,omitempty"`
+}
+
+type gamePage struct {
+	Total    int                 `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page-size"`
+	Games    []domain.ClientGame `json:"games"`
+}
+
+type igdbOverride struct {
+	IgdbId int `json:"igdb-id"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// gameSortIndexes are the orders of GET /games the store keeps an index for, by the name of the sort parameter.
+var gameSortIndexes = map[string]string{
+	"release": store.ReleaseDateIndex,
+	"rating":  store.RatingIndex,
+}
+
+// gameSorts are the other orders of GET /games, sorted when requested, by the name of the sort parameter.
+var gameSorts = map[string]func(first domain.ClientGame, second domain.ClientGame) bool{
+	"name": func(first domain.ClientGame, second domain.ClientGame) bool {
+		return strings.ToLower(first.Name) < strings.ToLower(second.Name)
+	},
+	"user-rating": func(first domain.ClientGame, second domain.ClientGame) bool {
+		return first.UserRating < second.UserRating
+	},
+	"playtime": func(first domain.ClientGame, second domain.ClientGame) bool {
+		return first.PlaytimeForever < second.PlaytimeForever
+	},
+	"last-played": func(first domain.ClientGame, second domain.ClientGame) bool {
+		return first.LastPlayed.Before(second.LastPlayed)
+	},
+	"last-launched": func(first domain.ClientGame, second domain.ClientGame) bool {
+		return first.LastLaunched.Before(second.LastLaunched)
+	},
+	"launches": func(first domain.ClientGame, second domain.ClientGame) bool {
+		return first.LaunchCount < second.LaunchCount
+	},
+}
+
+// handleGames routes the game API:
+//
+//	GET /games?filter=&sort=&order=&page=&page-size=
+//	GET /games/{source}/{id}
+//	GET /games/{source}/{id}/artworks
+//	PUT /games/{source}/{id}/igdb
+//
+// The API covers all stored games, the filter of the slideshow does not apply.
+func (server *Server) handleGames(writer http.ResponseWriter, request *http.Request) {
+	pathParts := strings.Split(strings.Trim(strings.TrimPrefix(request.URL.Path, "/games"), "/"), "/")
+	switch {
+	case len(pathParts) == 1 && pathParts[0] == "":
+		if checkMethod(writer, request, http.MethodGet) {
+			server.handleGameList(writer, request)
+		}
+	case len(pathParts) == 2:
+		if checkMethod(writer, request, http.MethodGet) {
+			if game, found := server.getApiGame(writer, pathParts[0], pathParts[1]); found {
+				writeJson(writer, game)
+			}
+		}
+	case len(pathParts) == 3 && pathParts[2] == "artworks":
+		if checkMethod(writer, request, http.MethodGet) {
+			if game, found := server.getApiGame(writer, pathParts[0], pathParts[1]); found {
+				server.handleArtworks(writer, game)
+			}
+		}
+	case len(pathParts) == 3 && pathParts[2] == "igdb":
+		if checkMethod(writer, request, http.MethodPut) && server.authorized(writer, request) {
+			if game, found := server.getApiGame(writer, pathParts[0], pathParts[1]); found {
+				server.handleIgdbOverride(writer, request, game)
+			}
+		}
+	default:
+		writeError(writer, http.StatusNotFound, errors.New("Unknown API path "+request.URL.Path))
+	}
+}
+
+func (server *Server) handleGameList(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	sortName := query.Get("sort")
+	if sortName == "" {
+		sortName = "name"
+	}
+	sortIndex, indexed := gameSortIndexes[sortName]
+	less, sortFound := gameSorts[sortName]
+	if !indexed && !sortFound {
+		writeError(writer, http.StatusBadRequest, errors.New("Unknown sort "+sortName))
+		return
+	}
+	descending := query.Get("order") == "desc"
+
+	var games []domain.ClientGame
+	var getErr error
+	if indexed {
+		games, getErr = server.store.GetGamesOrderedBy(sortIndex, descending)
+	} else {
+		games, getErr = server.store.GetGames()
+	}
+	if getErr != nil {
+		writeError(writer, http.StatusInternalServerError, getErr)
+		return
+	}
+	if filterExpression := query.Get("filter"); filterExpression != "" {
+		gameFilter, filterErr := filter.Parse(filterExpression)
+		if filterErr != nil {
+			writeError(writer, http.StatusBadRequest, filterErr)
+			return
+		}
+		games = filter.Apply(games, gameFilter)
+	}
+
+	if !indexed {
+		sort.SliceStable(games, func(i, j int) bool {
+			if descending {
+				return less(games[j], games[i])
+			}
+			return less(games[i], games[j])
+		})
+	}
+
+	page, pageErr := getIntParameter(query.Get("page"), 1)
+	pageSize, pageSizeErr := getIntParameter(query.Get("page-size"), defaultPageSize)
+	if pageErr != nil || pageSizeErr != nil || page < 1 || pageSize < 1 || pageSize > maxPageSize {
+		writeError(writer, http.StatusBadRequest, errors.New("page must be at least 1 and page-size between 1 and "+strconv.Itoa(maxPageSize)))
+		return
+	}
+	start := (page - 1) * pageSize
+	if start > len(games) {
+		start = len(games)
+	}
+	end := start + pageSize
+	if end > len(games) {
+		end = len(games)
+	}
+	writeJson(writer, gamePage{Total: len(games), Page: page, PageSize: pageSize, Games: games[start:end]})
+}
+
+func getIntParameter(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// getApiGame returns the stored game of the source and source id, answering not found when there is none.
+func (server *Server) getApiGame(writer http.ResponseWriter, sourceName string, sourceId string) (domain.ClientGame, bool) {
+	source := domain.ParseGameSource(sourceName)
+	if source == domain.UnknownGameSource {
+		writeError(writer, http.StatusNotFound, errors.New("Unknown game source "+sourceName))
+		return domain.ClientGame{}, false
+	}
+	game, getErr := server.store.GetGame(domain.ClientGame{Source: source, SourceId: sourceId}.Key())
+	if getErr == buntdb.ErrNotFound {
+		writeError(writer, http.StatusNotFound, errors.New("No game "+sourceId+" of source "+source.String()))
+		return domain.ClientGame{}, false
+	}
+	if getErr != nil {
+		writeError(writer, http.StatusInternalServerError, getErr)
+		return domain.ClientGame{}, false
+	}
+	return game, true
+}
+
+// handleArtworks lists the artworks the visualizer shows for the game, local artworks included.
+func (server *Server) handleArtworks(writer http.ResponseWriter, game domain.ClientGame) {
+	if server.localArtworks != nil {
+		game = server.localArtworks.Apply(game)
+	}
+	artworks := game.Artworks
+	if artworks == nil {
+		artworks = []domain.GameArtwork{}
+	}
+	writeJson(writer, artworks)
+}
+
+// handleIgdbOverride matches the game with the IGDB game of the id in the body, e.g. {"igdb-id": 1942}, and answers
+// the updated game.
+func (server *Server) handleIgdbOverride(writer http.ResponseWriter, request *http.Request, game domain.ClientGame) {
+	var override igdbOverride
+	decodeErr := json.NewDecoder(request.Body).Decode(&override)
+	if decodeErr != nil || override.IgdbId < 1 {
+		writeError(writer, http.StatusBadRequest, errors.New("Body must be {\"igdb-id\": id} with the id of the IGDB game"))
+		return
+	}
+	if server.syncer == nil {
+		writeError(writer, http.StatusNotImplemented, errors.New("Matching IGDB games is not available"))
+		return
+	}
+	matchedGame, matchErr := server.syncer.MatchIgdbGame(game, override.IgdbId)
+	if matchErr != nil {
+		writeError(writer, http.StatusBadGateway, matchErr)
+		return
+	}
+	saveErr := server.store.SaveGame(matchedGame)
+	if saveErr != nil {
+		writeError(writer, http.StatusInternalServerError, saveErr)
+		return
+	}
+	writeJson(writer, matchedGame)
+}
+
+// handleSync starts a sync on POST and answers the sync status on GET. Only one sync runs at a time.
+func (server *Server) handleSync(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		server.syncStatus.mutex.Lock()
+		defer server.syncStatus.mutex.Unlock()
+		writeJson(writer, &server.syncStatus)
+	case http.MethodPost:
+		if !server.authorized(writer, request) {
+			return
+		}
+		if server.syncer == nil {
+			writeError(writer, http.StatusNotImplemented, errors.New("Syncing is not available"))
+			return
+		}
+		server.syncStatus.mutex.Lock()
+		defer server.syncStatus.mutex.Unlock()
+		if server.syncStatus.Running {
+			writeError(writer, http.StatusConflict, errors.New("A sync is already running"))
+			return
+		}
+		server.syncStatus.Running = true
+		go server.runSync()
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(writer).Encode(&server.syncStatus)
+	default:
+		writeError(writer, http.StatusMethodNotAllowed, errors.New("Method "+request.Method+" not allowed"))
+	}
+}
+
+func (server *Server) runSync() {
+	syncErr := server.syncer.SyncGames()
+	server.syncStatus.mutex.Lock()
+	defer server.syncStatus.mutex.Unlock()
+	server.syncStatus.Running = false
+	finished := time.Now()
+	server.syncStatus.LastFinished = &finished
+	server.syncStatus.LastError = ""
+	if syncErr != nil {
+		server.syncStatus.LastError = syncErr.Error()
+	}
+}
+
+// authorized tells if the request carries the API token as bearer token and answers it with an error otherwise. The
+// API only changes the library with a token configured, anyone on the network could otherwise.
+func (server *Server) authorized(writer http.ResponseWriter, request *http.Request) bool {
+	if server.config.Token == "" {
+		writeError(writer, http.StatusForbidden, errors.New("No API token configured in server.token, the API cannot change the library"))
+		return false
+	}
+	token := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(server.config.Token)) != 1 {
+		writeError(writer, http.StatusUnauthorized, errors.New("Invalid token"))
+		return false
+	}
+	return true
+}
+
+func checkMethod(writer http.ResponseWriter, request *http.Request, method string) bool {
+	if request.Method != method {
+		writeError(writer, http.StatusMethodNotAllowed, errors.New("Method "+request.Method+" not allowed"))
+		return false
+	}
+	return true
+}
+
+func writeError(writer http.ResponseWriter, statusCode int, err error) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	json.NewEncoder(writer).Encode(apiError{Error: err.Error()})
+}
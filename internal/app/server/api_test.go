@@ -0,0 +1,205 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/filter"
+	"vg-cover-screen-saver-go/internal/app/selection"
+	"vg-cover-screen-saver-go/internal/app/store"
+)
+
+// fakeSyncer syncs until released, so a second sync can be started while the first runs.
+type fakeSyncer struct {
+	release chan bool
+}
+
+func (syncer fakeSyncer) SyncGames() error {
+	<-syncer.release
+	return nil
+}
+
+func (syncer fakeSyncer) MatchIgdbGame(game domain.ClientGame, igdbId int) (domain.ClientGame, error) {
+	game.IgdbId = igdbId
+	return game, nil
+}
+
+func newTestServer(t *testing.T, token string) (*Server, fakeSyncer) {
+	gameStore, openErr := store.Open(":memory:")
+	if openErr != nil {
+		t.Fatalf("Open failed: %v", openErr)
+	}
+	t.Cleanup(func() { gameStore.Close() })
+	games := []domain.ClientGame{
+		{Name: "Portal 2", Source: domain.Steam, SourceId: "620", PlaytimeForever: 600, AggregatedRating: 95, Favourite: true,
+			FirstReleaseDate: time.Date(2011, 4, 18, 0, 0, 0, 0, time.UTC),
+			Artworks:         []domain.GameArtwork{{Id: 1, ArtworkId: "cover", Type: domain.Cover}}},
+		{Name: "half-life", Source: domain.Steam, SourceId: "70", PlaytimeForever: 60, AggregatedRating: 100,
+			FirstReleaseDate: time.Date(1998, 11, 19, 0, 0, 0, 0, time.UTC)},
+		{Name: "Stardew Valley", Source: domain.Steam, SourceId: "413150", PlaytimeForever: 6000, AggregatedRating: 89,
+			FirstReleaseDate: time.Date(2016, 2, 26, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, game := range games {
+		if err := gameStore.SaveGame(game); err != nil {
+			t.Fatalf("SaveGame failed: %v", err)
+		}
+	}
+	matchAll, _ := filter.Parse("")
+	syncer := fakeSyncer{release: make(chan bool)}
+	config := Config{Token: token, ImageSeconds: 5, BackgroundTransitions: 1}
+	return New(config, gameStore, matchAll, selection.New("random", 0, nil), nil, nil, syncer), syncer
+}
+
+func request(server *Server, method string, path string, token string, body string) *httptest.ResponseRecorder {
+	apiRequest := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		apiRequest.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, apiRequest)
+	return recorder
+}
+
+func TestGameList(t *testing.T) {
+	server, _ := newTestServer(t, "")
+	tests := []struct {
+		query string
+		total int
+		names []string
+	}{
+		{"", 3, []string{"half-life", "Portal 2", "Stardew Valley"}},
+		{"?order=desc", 3, []string{"Stardew Valley", "Portal 2", "half-life"}},
+		{"?sort=release", 3, []string{"half-life", "Portal 2", "Stardew Valley"}},
+		{"?sort=release&order=desc", 3, []string{"Stardew Valley", "Portal 2", "half-life"}},
+		{"?sort=rating&order=desc", 3, []string{"half-life", "Portal 2", "Stardew Valley"}},
+		{"?sort=playtime", 3, []string{"half-life", "Portal 2", "Stardew Valley"}},
+		{"?filter=favourite", 1, []string{"Portal 2"}},
+		{"?filter=playtime>=600&sort=rating", 2, []string{"Stardew Valley", "Portal 2"}},
+		{"?page=2&page-size=2", 3, []string{"Stardew Valley"}},
+		{"?page=3&page-size=2", 3, []string{}},
+	}
+	for _, test := range tests {
+		response := request(server, http.MethodGet, "/games"+test.query, "", "")
+		if response.Code != http.StatusOK {
+			t.Errorf("GET /games%s = %d, want 200: %s", test.query, response.Code, response.Body.String())
+			continue
+		}
+		var page gamePage
+		if err := json.Unmarshal(response.Body.Bytes(), &page); err != nil {
+			t.Fatalf("GET /games%s answered no game page: %v", test.query, err)
+		}
+		names := []string{}
+		for _, game := range page.Games {
+			names = append(names, game.Name)
+		}
+		if page.Total != test.total || strings.Join(names, ",") != strings.Join(test.names, ",") {
+			t.Errorf("GET /games%s = %d games %v, want %d games %v", test.query, page.Total, names, test.total, test.names)
+		}
+	}
+}
+
+func TestGameListBadParameters(t *testing.T) {
+	server, _ := newTestServer(t, "")
+	for _, query := range []string{"?page=0", "?page=two", "?page-size=0", "?page-size=501", "?sort=colour", "?filter=developer%3D"} {
+		if response := request(server, http.MethodGet, "/games"+query, "", ""); response.Code != http.StatusBadRequest {
+			t.Errorf("GET /games%s = %d, want 400", query, response.Code)
+		}
+	}
+}
+
+func TestGameRoutes(t *testing.T) {
+	server, _ := newTestServer(t, "secret")
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/games/steam/620", http.StatusOK},
+		{http.MethodGet, "/games/Steam/620/", http.StatusOK},
+		{http.MethodGet, "/games/steam/620/artworks", http.StatusOK},
+		{http.MethodGet, "/games/steam/999", http.StatusNotFound},
+		{http.MethodGet, "/games/origin/620", http.StatusNotFound},
+		{http.MethodGet, "/games/steam/620/screenshots", http.StatusNotFound},
+		{http.MethodGet, "/games/steam/620/igdb/extra", http.StatusNotFound},
+		{http.MethodPost, "/games", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/games/steam/620", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/games/steam/620/igdb", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		if response := request(server, test.method, test.path, "", ""); response.Code != test.status {
+			t.Errorf("%s %s = %d, want %d", test.method, test.path, response.Code, test.status)
+		}
+	}
+
+	var game domain.ClientGame
+	json.Unmarshal(request(server, http.MethodGet, "/games/steam/620", "", "").Body.Bytes(), &game)
+	if game.Name != "Portal 2" {
+		t.Errorf("GET /games/steam/620 = %s, want Portal 2", game.Name)
+	}
+	var artworks []domain.GameArtwork
+	json.Unmarshal(request(server, http.MethodGet, "/games/steam/620/artworks", "", "").Body.Bytes(), &artworks)
+	if len(artworks) != 1 || artworks[0].Id != 1 {
+		t.Errorf("GET /games/steam/620/artworks = %+v, want the cover", artworks)
+	}
+}
+
+func TestIgdbOverride(t *testing.T) {
+	tests := []struct {
+		name        string
+		serverToken string
+		token       string
+		body        string
+		status      int
+	}{
+		{"no token configured", "", "secret", `{"igdb-id": 1942}`, http.StatusForbidden},
+		{"no token sent", "secret", "", `{"igdb-id": 1942}`, http.StatusUnauthorized},
+		{"wrong token", "secret", "guess", `{"igdb-id": 1942}`, http.StatusUnauthorized},
+		{"no id", "secret", "secret", `{}`, http.StatusBadRequest},
+		{"invalid body", "secret", "secret", `1942`, http.StatusBadRequest},
+		{"matched", "secret", "secret", `{"igdb-id": 1942}`, http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newTestServer(t, test.serverToken)
+			response := request(server, http.MethodPut, "/games/steam/620/igdb", test.token, test.body)
+			if response.Code != test.status {
+				t.Fatalf("PUT igdb = %d, want %d: %s", response.Code, test.status, response.Body.String())
+			}
+			stored, _ := server.store.GetGame("Steam620")
+			if matched := stored.IgdbId == 1942; matched != (test.status == http.StatusOK) {
+				t.Errorf("stored IGDB id = %d after answer %d", stored.IgdbId, response.Code)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	server, syncer := newTestServer(t, "secret")
+	if response := request(server, http.MethodPost, "/sync", "", ""); response.Code != http.StatusUnauthorized {
+		t.Errorf("POST /sync without token = %d, want 401", response.Code)
+	}
+	if response := request(server, http.MethodPost, "/sync", "secret", ""); response.Code != http.StatusAccepted {
+		t.Fatalf("POST /sync = %d, want 202", response.Code)
+	}
+	if response := request(server, http.MethodPost, "/sync", "secret", ""); response.Code != http.StatusConflict {
+		t.Errorf("POST /sync while syncing = %d, want 409", response.Code)
+	}
+	syncer.release <- true
+
+	for attempt := 0; attempt < 100; attempt++ {
+		var status struct {
+			Running      bool       `json:"running"`
+			LastFinished *time.Time `json:"last-finished"`
+		}
+		json.Unmarshal(request(server, http.MethodGet, "/sync", "", "").Body.Bytes(), &status)
+		if !status.Running && status.LastFinished != nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("GET /sync still running after the sync finished")
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"github.com/magiconair/properties"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/filter"
+	"vg-cover-screen-saver-go/internal/app/imagecache"
+	"vg-cover-screen-saver-go/internal/app/localart"
+	"vg-cover-screen-saver-go/internal/app/selection"
+	"vg-cover-screen-saver-go/internal/app/store"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+var slideshowTemplate = template.Must(template.ParseFS(staticFiles, "static/slideshow.html"))
+
+// Config is the address the server listens on, the token of the API requests changing the library and the slideshow
+// timing, the same as the desktop visualizer's. Synced slideshows show the same game in every browser, otherwise each
+// browser picks its own games. Sync is the default of the slideshow page, a browser opening /?sync=false or
+// /?sync=true picks for itself.
+type Config struct {
+	Address               string
+	Token                 string
+	Sync                  bool
+	ImageSeconds          int
+	BackgroundTransitions int
+}
+
+// LoadConfig reads the API token from the secret properties and the rest from the main properties.
+func LoadConfig(props properties.Properties, secretProps properties.Properties) Config {
+	return Config{
+		Address:               props.GetString("visualizer.server.address", ":8080"),
+		Token:                 secretProps.GetString("server.token", ""),
+		Sync:                  props.GetBool("visualizer.server.sync", true),
+		ImageSeconds:          props.GetInt("visualizer.image.time.seconds", 5),
+		BackgroundTransitions: props.GetInt("visualizer.image.background.transitions", 3),
+	}
+}
+
+// ImageSource returns the local file of an artwork URL, the image cache downloads the artworks not cached yet.
+type ImageSource interface {
+	GetPath(imageUrl string) (string, error)
+}
+
+// Server serves the library, the cached artworks and a browser slideshow over HTTP.
+type Server struct {
+	config        Config
+	store         *store.Store
+	filter        filter.Filter
+	selection     selection.Strategy
+	localArtworks *localart.Library
+	images        ImageSource
+	syncer        Syncer
+	syncStatus    syncStatus
+	imageMutex    sync.RWMutex
+	imageUrls     map[string]string
+	slideshow     *slideshow
+}
+
+// New creates the server of the games in the store matching the filter. The selection picks the games of the synced
+// slideshow. Local artworks are optional, nil uses the stored artworks only. Without syncer the API cannot sync or
+// match IGDB games.
+func New(config Config, gameStore *store.Store, gameFilter filter.Filter, gameSelection selection.Strategy, localArtworks *localart.Library, images ImageSource, syncer Syncer) *Server {
+	server := &Server{
+		config:        config,
+		store:         gameStore,
+		filter:        gameFilter,
+		selection:     gameSelection,
+		localArtworks: localArtworks,
+		images:        images,
+		syncer:        syncer,
+		imageUrls:     make(map[string]string),
+	}
+	server.slideshow = newSlideshow(server)
+	return server
+}
+
+// Handler returns the routes of the server.
+func (server *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	staticRoot, _ := fs.Sub(staticFiles, "static")
+	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(staticRoot))))
+	mux.HandleFunc("/images/", server.handleImage)
+	mux.HandleFunc("/slideshow/games", server.handleSlideshowGames)
+	mux.HandleFunc("/slideshow/current", server.handleCurrentSlide)
+	mux.HandleFunc("/games", server.handleGames)
+	mux.HandleFunc("/games/", server.handleGames)
+	mux.HandleFunc("/sync", server.handleSync)
+	mux.HandleFunc("/", server.handleSlideshowPage)
+	return mux
+}
+
+// ListenAndServe serves until the server fails.
+func (server *Server) ListenAndServe() error {
+	return http.ListenAndServe(server.config.Address, server.Handler())
+}
+
+// getGames returns the stored games matching the filter with their local artworks applied. Their artworks become
+// available under /images.
+func (server *Server) getGames() ([]domain.ClientGame, error) {
+	storedGames, getErr := server.store.GetGames()
+	if getErr != nil {
+		return nil, getErr
+	}
+	var games []domain.ClientGame
+	imageUrls := make(map[string]string)
+	for _, game := range filter.Apply(storedGames, server.filter) {
+		if server.localArtworks != nil {
+			game = server.localArtworks.Apply(game)
+		}
+		for _, artwork := range game.Artworks {
+			imageUrls[imagecache.GetFileName(artwork.Url())] = artwork.Url()
+		}
+		games = append(games, game)
+	}
+	server.imageMutex.Lock()
+	server.imageUrls = imageUrls
+	server.imageMutex.Unlock()
+	return games, nil
+}
+
+// getImagePath is the path under which the server serves the artwork.
+func getImagePath(artwork domain.GameArtwork) string {
+	return "/images/" + imagecache.GetFileName(artwork.Url())
+}
+
+// handleImage serves an artwork of the library from the image cache. Only artworks of the games the slideshow loaded
+// last are served, the server does not fetch arbitrary URLs. Unknown images are not found without loading the games
+// again, the slideshow loads them for every game it picks and every browser fetching the game list.
+func (server *Server) handleImage(writer http.ResponseWriter, request *http.Request) {
+	fileName := strings.TrimPrefix(request.URL.Path, "/images/")
+	server.imageMutex.RLock()
+	imageUrl, found := server.imageUrls[fileName]
+	server.imageMutex.RUnlock()
+	if !found {
+		http.NotFound(writer, request)
+		return
+	}
+	imagePath, pathErr := server.images.GetPath(imageUrl)
+	if pathErr != nil {
+		http.Error(writer, pathErr.Error(), http.StatusBadGateway)
+		return
+	}
+	writer.Header().Set("Cache-Control", "max-age=86400")
+	http.ServeFile(writer, request, imagePath)
+}
+
+func (server *Server) handleSlideshowPage(writer http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != "/" {
+		http.NotFound(writer, request)
+		return
+	}
+	pageConfig := server.config
+	if syncValue := request.URL.Query().Get("sync"); syncValue != "" {
+		pageConfig.Sync = syncValue == "true"
+	}
+	// The page is written once complete, a failing template answers an error instead of half a page
+	var page bytes.Buffer
+	executeErr := slideshowTemplate.Execute(&page, pageConfig)
+	if executeErr != nil {
+		http.Error(writer, executeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page.WriteTo(writer)
+}
+
+// slideshowGame is a game as the browser slideshow shows it.
+type slideshowGame struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Cover       string   `json:"cover"`
+	Backgrounds []string `json:"backgrounds"`
+}
+
+func newSlideshowGame(game domain.ClientGame) (slideshowGame, bool) {
+	cover, coverFound := game.Cover()
+	if !coverFound {
+		return slideshowGame{}, false
+	}
+	shownGame := slideshowGame{Key: game.Key(), Name: game.Name, Cover: getImagePath(cover), Backgrounds: []string{}}
+	for _, background := range game.Backgrounds() {
+		shownGame.Backgrounds = append(shownGame.Backgrounds, getImagePath(background))
+	}
+	return shownGame, true
+}
+
+// handleSlideshowGames lists the games with cover for browsers picking their own games.
+func (server *Server) handleSlideshowGames(writer http.ResponseWriter, request *http.Request) {
+	games, getErr := server.getGames()
+	if getErr != nil {
+		http.Error(writer, getErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	shownGames := make([]slideshowGame, 0, len(games))
+	for _, game := range games {
+		if shownGame, shown := newSlideshowGame(game); shown {
+			shownGames = append(shownGames, shownGame)
+		}
+	}
+	writeJson(writer, shownGames)
+}
+
+// handleCurrentSlide returns what the synced slideshow shows right now. The synced slideshow starts with the first
+// browser asking for it and keeps running, so browsers joining later show the game the others show.
+func (server *Server) handleCurrentSlide(writer http.ResponseWriter, request *http.Request) {
+	server.slideshow.start()
+	slide, found := server.slideshow.current()
+	if !found {
+		http.Error(writer, "No games to show", http.StatusServiceUnavailable)
+		return
+	}
+	writeJson(writer, slide)
+}
+
+func writeJson(writer http.ResponseWriter, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(value)
+}
+
+// secondsDuration converts possibly fractional seconds to a duration.
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
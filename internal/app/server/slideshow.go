@@ -0,0 +1,111 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+// Slide is what a synced slideshow shows: the game's cover over one of its backgrounds, for the seconds left.
+type Slide struct {
+	Id         int     `json:"id"`
+	Key        string  `json:"key"`
+	Name       string  `json:"name"`
+	Cover      string  `json:"cover"`
+	Background string  `json:"background,omitempty"`
+	Seconds    float64 `json:"seconds"`
+}
+
+// slideshow is the clock of the synced slideshow. It picks the games like the desktop visualizer and shows each game
+// with several backgrounds.
+type slideshow struct {
+	server  *Server
+	started sync.Once
+	mutex   sync.Mutex
+	slide   Slide
+	until   time.Time
+	shown   bool
+}
+
+func newSlideshow(server *Server) *slideshow {
+	return &slideshow{server: server}
+}
+
+func (show *slideshow) start() {
+	show.started.Do(func() {
+		go show.run()
+		// The first slide is there before the browser asking for it gets its answer
+		for i := 0; i < 50; i++ {
+			if _, shown := show.current(); shown {
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	})
+}
+
+func (show *slideshow) run() {
+	transitions := show.server.config.BackgroundTransitions
+	if transitions < 1 {
+		transitions = 1
+	}
+	backgroundDuration := secondsDuration(float64(show.server.config.ImageSeconds) / float64(transitions))
+	for {
+		shownGame, found := show.pickGame()
+		if !found {
+			time.Sleep(backgroundDuration)
+			continue
+		}
+		for i := 0; i <= transitions; i++ {
+			slide := Slide{Key: shownGame.Key, Name: shownGame.Name, Cover: shownGame.Cover}
+			if len(shownGame.Backgrounds) > 0 {
+				slide.Background = shownGame.Backgrounds[rand.Intn(len(shownGame.Backgrounds))]
+			}
+			show.show(slide, backgroundDuration)
+			time.Sleep(backgroundDuration)
+		}
+	}
+}
+
+func (show *slideshow) pickGame() (slideshowGame, bool) {
+	games, getErr := show.server.getGames()
+	if getErr != nil {
+		fmt.Println("Loading the slideshow games failed! " + getErr.Error())
+		return slideshowGame{}, false
+	}
+	// The selection picks among the games with cover, the ones the slideshow can show
+	var coverGames []domain.ClientGame
+	var shownGames []slideshowGame
+	for _, game := range games {
+		if shownGame, shown := newSlideshowGame(game); shown {
+			coverGames = append(coverGames, game)
+			shownGames = append(shownGames, shownGame)
+		}
+	}
+	if len(shownGames) == 0 {
+		return slideshowGame{}, false
+	}
+	return shownGames[show.server.selection.Next(coverGames, nil)], true
+}
+
+func (show *slideshow) show(slide Slide, duration time.Duration) {
+	show.mutex.Lock()
+	defer show.mutex.Unlock()
+	slide.Id = show.slide.Id + 1
+	show.slide = slide
+	show.until = time.Now().Add(duration)
+	show.shown = true
+}
+
+func (show *slideshow) current() (Slide, bool) {
+	show.mutex.Lock()
+	defer show.mutex.Unlock()
+	slide := show.slide
+	slide.Seconds = time.Until(show.until).Seconds()
+	if slide.Seconds < 0 {
+		slide.Seconds = 0
+	}
+	return slide, show.shown
+}
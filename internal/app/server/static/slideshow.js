@@ -0,0 +1,79 @@
+// Shows the games like the desktop visualizer: a cover over its blurred backgrounds, the background changing several
+// times before the next game. A synced slideshow shows what the server's slideshow shows, otherwise the browser picks
+// its own games at random.
+(function () {
+  var settings = document.body.dataset;
+  var background = document.getElementById("background");
+  var cover = document.getElementById("cover");
+  var caption = document.getElementById("caption");
+  var transitions = Math.max(Number(settings.backgroundTransitions), 1);
+  var backgroundMillis = 1000 * Number(settings.imageSeconds) / transitions;
+
+  function show(name, coverUrl, backgroundUrl) {
+    if (cover.getAttribute("src") !== coverUrl) {
+      cover.src = coverUrl;
+      cover.alt = name;
+    }
+    caption.textContent = name;
+    background.style.backgroundImage = backgroundUrl ? "url('" + backgroundUrl + "')" : "none";
+  }
+
+  function getJson(url) {
+    return fetch(url).then(function (response) {
+      if (!response.ok) {
+        throw new Error(url + " answered " + response.status);
+      }
+      return response.json();
+    });
+  }
+
+  function runSynced() {
+    var shownId = 0;
+    (function poll() {
+      getJson("/slideshow/current").then(function (slide) {
+        if (slide.id !== shownId) {
+          shownId = slide.id;
+          show(slide.name, slide.cover, slide.background);
+        }
+        // The next slide is fetched right when the server switches to it
+        setTimeout(poll, Math.max(200, slide.seconds * 1000 + 100));
+      }).catch(function () {
+        setTimeout(poll, 5000);
+      });
+    })();
+  }
+
+  function runIndependent() {
+    var games = [];
+    function showGame() {
+      var game = games[Math.floor(Math.random() * games.length)];
+      var shown = 0;
+      (function showBackground() {
+        var backgroundUrl = game.backgrounds.length > 0 ? game.backgrounds[Math.floor(Math.random() * game.backgrounds.length)] : "";
+        show(game.name, game.cover, backgroundUrl);
+        shown++;
+        setTimeout(shown <= transitions ? showBackground : loadGames, backgroundMillis);
+      })();
+    }
+    // The games are loaded again for every game so changes to the library show up
+    function loadGames() {
+      getJson("/slideshow/games").then(function (loadedGames) {
+        games = loadedGames;
+      }).catch(function () {
+      }).then(function () {
+        if (games.length > 0) {
+          showGame();
+        } else {
+          setTimeout(loadGames, 5000);
+        }
+      });
+    }
+    loadGames();
+  }
+
+  if (settings.sync === "true") {
+    runSynced();
+  } else {
+    runIndependent();
+  }
+})();
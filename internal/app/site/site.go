@@ -0,0 +1,265 @@
+package site
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"github.com/magiconair/properties"
+	xdraw "golang.org/x/image/draw"
+	"html"
+	"html/template"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/domain"
+	"vg-cover-screen-saver-go/internal/app/imagecache"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var pageTemplates = template.Must(template.ParseFS(templateFiles, "templates/*.html"))
+
+// Config is the title and slideshow timing of the site and the size of the cover thumbnails, no thumbnails are made
+// with a width of 0.
+type Config struct {
+	Title                 string
+	ThumbnailWidth        int
+	ImageSeconds          int
+	BackgroundTransitions int
+}
+
+func LoadConfig(props properties.Properties) Config {
+	return Config{
+		Title:                 props.GetString("visualizer.site.title", "Game Library"),
+		ThumbnailWidth:        props.GetInt("visualizer.site.thumbnail.width", 300),
+		ImageSeconds:          props.GetInt("visualizer.image.time.seconds", 5),
+		BackgroundTransitions: props.GetInt("visualizer.image.background.transitions", 3),
+	}
+}
+
+// ImageSource returns the local file of an artwork URL, the image cache downloads the artworks not cached yet.
+type ImageSource interface {
+	GetPath(imageUrl string) (string, error)
+}
+
+// Game is a game as the site pages and scripts see it, with the artworks as paths relative to the site root.
+type Game struct {
+	Name         string   `json:"name"`
+	Page         string   `json:"page"`
+	Source       string   `json:"source"`
+	Developers   []string `json:"developers"`
+	Genres       []string `json:"genres"`
+	Year         int      `json:"year,omitempty"`
+	Released     string   `json:"released,omitempty"`
+	Rating       int      `json:"rating,omitempty"`
+	Playtime     int      `json:"playtime"`
+	PlaytimeText string   `json:"playtime-text"`
+	Summary      string   `json:"summary,omitempty"`
+	Cover        string   `json:"cover,omitempty"`
+	Thumbnail    string   `json:"thumbnail,omitempty"`
+	Logo         string   `json:"logo,omitempty"`
+	Backgrounds  []string `json:"backgrounds"`
+}
+
+type page struct {
+	Config Config
+	Root   string
+	Games  []Game
+	Game   Game
+}
+
+// Generate writes a self-contained site of the games to the output directory: an index of covers with search and
+// filters, a page per game and a slideshow. The artworks are copied next to the pages so the site works from any web
+// server or straight from disk. Artworks that cannot be loaded are left out.
+func Generate(games []domain.ClientGame, output string, images ImageSource, config Config) error {
+	for _, directory := range []string{"games", "images", "thumbnails"} {
+		mkdirErr := os.MkdirAll(filepath.Join(output, directory), 0755)
+		if mkdirErr != nil {
+			return mkdirErr
+		}
+	}
+
+	var siteGames []Game
+	for _, game := range games {
+		siteGame := convertGame(game)
+		if cover, coverFound := game.Cover(); coverFound {
+			siteGame.Cover = copyImage(cover.Url(), output, images)
+			if siteGame.Cover != "" && config.ThumbnailWidth > 0 {
+				siteGame.Thumbnail = writeThumbnail(filepath.Join(output, siteGame.Cover), output, config.ThumbnailWidth)
+			}
+		}
+		if logo, logoFound := game.Logo(); logoFound {
+			siteGame.Logo = copyImage(logo.Url(), output, images)
+		}
+		for _, background := range game.Backgrounds() {
+			if backgroundPath := copyImage(background.Url(), output, images); backgroundPath != "" {
+				siteGame.Backgrounds = append(siteGame.Backgrounds, backgroundPath)
+			}
+		}
+		siteGames = append(siteGames, siteGame)
+		fmt.Println(game.Name)
+	}
+
+	for _, siteGame := range siteGames {
+		pageErr := writePage(filepath.Join(output, siteGame.Page), "game.html", page{Config: config, Root: "../", Game: siteGame})
+		if pageErr != nil {
+			return pageErr
+		}
+	}
+	for _, pageName := range []string{"index.html", "slideshow.html"} {
+		pageErr := writePage(filepath.Join(output, pageName), pageName, page{Config: config, Games: siteGames})
+		if pageErr != nil {
+			return pageErr
+		}
+	}
+	libraryErr := writeLibraryScript(filepath.Join(output, "library.js"), siteGames, config)
+	if libraryErr != nil {
+		return libraryErr
+	}
+	return copyStaticFiles(output)
+}
+
+func convertGame(game domain.ClientGame) Game {
+	siteGame := Game{
+		Name:         game.Name,
+		Page:         "games/" + strings.ToLower(game.Source.String()) + "-" + game.SourceId + ".html",
+		Source:       game.Source.String(),
+		Developers:   game.Developers,
+		Genres:       game.Genres,
+		Rating:       int(game.AggregatedRating + 0.5),
+		Playtime:     game.PlaytimeForever,
+		PlaytimeText: domain.FormatPlaytime(game.PlaytimeForever),
+		Summary:      game.Summary,
+		Backgrounds:  []string{},
+	}
+	if siteGame.Summary == "" {
+		siteGame.Summary = html.UnescapeString(game.Description)
+	}
+	if !game.FirstReleaseDate.IsZero() {
+		siteGame.Year = game.FirstReleaseDate.Year()
+		siteGame.Released = game.FirstReleaseDate.Format("January 2, 2006")
+	}
+	return siteGame
+}
+
+// copyImage copies the artwork from the image cache to the images directory of the site and returns its path relative
+// to the site root. Images copied before are not copied again.
+func copyImage(imageUrl string, output string, images ImageSource) string {
+	relativePath := "images/" + imagecache.GetFileName(imageUrl)
+	targetPath := filepath.Join(output, filepath.FromSlash(relativePath))
+	if _, statErr := os.Stat(targetPath); statErr == nil {
+		return relativePath
+	}
+	sourcePath, pathErr := images.GetPath(imageUrl)
+	if pathErr != nil {
+		fmt.Println("Failed to load image " + imageUrl + " " + pathErr.Error())
+		return ""
+	}
+	copyErr := copyFile(sourcePath, targetPath)
+	if copyErr != nil {
+		fmt.Println("Failed to copy image " + imageUrl + " " + copyErr.Error())
+		return ""
+	}
+	return relativePath
+}
+
+func copyFile(sourcePath string, targetPath string) error {
+	source, openErr := os.Open(sourcePath)
+	if openErr != nil {
+		return openErr
+	}
+	defer source.Close()
+	target, createErr := os.Create(targetPath)
+	if createErr != nil {
+		return createErr
+	}
+	_, copyErr := io.Copy(target, source)
+	closeErr := target.Close()
+	if copyErr != nil {
+		return copyErr
+	}
+	return closeErr
+}
+
+// writeThumbnail writes a JPEG of the cover scaled down to the width and returns its path relative to the site root, or
+// an empty path when the cover cannot be decoded. Covers narrower than the width are used as they are.
+func writeThumbnail(coverPath string, output string, width int) string {
+	coverFile, openErr := os.Open(coverPath)
+	if openErr != nil {
+		return ""
+	}
+	defer coverFile.Close()
+	cover, _, decodeErr := image.Decode(coverFile)
+	if decodeErr != nil || cover.Bounds().Dx() <= width {
+		return ""
+	}
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, cover.Bounds().Dy()*width/cover.Bounds().Dx()))
+	xdraw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), cover, cover.Bounds(), draw.Src, nil)
+
+	relativePath := "thumbnails/" + strings.TrimSuffix(filepath.Base(coverPath), filepath.Ext(coverPath)) + ".jpg"
+	thumbnailFile, createErr := os.Create(filepath.Join(output, filepath.FromSlash(relativePath)))
+	if createErr != nil {
+		return ""
+	}
+	encodeErr := jpeg.Encode(thumbnailFile, thumbnail, &jpeg.Options{Quality: 85})
+	closeErr := thumbnailFile.Close()
+	if encodeErr != nil || closeErr != nil {
+		return ""
+	}
+	return relativePath
+}
+
+func writePage(path string, templateName string, data page) error {
+	pageFile, createErr := os.Create(path)
+	if createErr != nil {
+		return createErr
+	}
+	executeErr := pageTemplates.ExecuteTemplate(pageFile, templateName, data)
+	closeErr := pageFile.Close()
+	if executeErr != nil {
+		return executeErr
+	}
+	return closeErr
+}
+
+// writeLibraryScript writes the games as a script rather than a JSON file, browsers do not let pages opened from disk
+// fetch files.
+func writeLibraryScript(path string, games []Game, config Config) error {
+	gamesJson, gamesErr := json.Marshal(games)
+	if gamesErr != nil {
+		return gamesErr
+	}
+	configJson, configErr := json.Marshal(map[string]int{
+		"imageSeconds":          config.ImageSeconds,
+		"backgroundTransitions": config.BackgroundTransitions,
+	})
+	if configErr != nil {
+		return configErr
+	}
+	script := "window.LIBRARY = " + string(gamesJson) + ";\nwindow.LIBRARY_CONFIG = " + string(configJson) + ";\n"
+	return os.WriteFile(path, []byte(script), 0644)
+}
+
+func copyStaticFiles(output string) error {
+	staticFiles, globErr := fs.Glob(templateFiles, "templates/*.[cj]s")
+	if globErr != nil {
+		return globErr
+	}
+	for _, staticFile := range staticFiles {
+		content, readErr := templateFiles.ReadFile(staticFile)
+		if readErr != nil {
+			return readErr
+		}
+		writeErr := os.WriteFile(filepath.Join(output, filepath.Base(staticFile)), content, 0644)
+		if writeErr != nil {
+			return writeErr
+		}
+	}
+	return nil
+}
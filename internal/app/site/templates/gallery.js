@@ -0,0 +1,64 @@
+// Filters and sorts the cover grid of the index page without reloading it.
+(function () {
+  var grid = document.getElementById("games");
+  var tiles = Array.prototype.slice.call(grid.querySelectorAll(".tile"));
+  var search = document.getElementById("search");
+  var source = document.getElementById("source");
+  var genre = document.getElementById("genre");
+  var played = document.getElementById("played");
+  var sort = document.getElementById("sort");
+  var count = document.getElementById("count");
+
+  function addOptions(select, values) {
+    values.sort().forEach(function (value) {
+      var option = document.createElement("option");
+      option.value = value;
+      option.textContent = value;
+      select.appendChild(option);
+    });
+  }
+
+  function unique(values) {
+    return values.filter(function (value, index) {
+      return value !== "" && values.indexOf(value) === index;
+    });
+  }
+
+  addOptions(source, unique(tiles.map(function (tile) { return tile.dataset.source; })));
+  addOptions(genre, unique([].concat.apply([], tiles.map(function (tile) { return tile.dataset.genres.split("|"); }))));
+
+  function update() {
+    var text = search.value.toLowerCase();
+    var shown = 0;
+    tiles.forEach(function (tile) {
+      var playtime = Number(tile.dataset.playtime);
+      var visible = tile.dataset.name.toLowerCase().indexOf(text) >= 0 &&
+        (source.value === "" || tile.dataset.source === source.value) &&
+        (genre.value === "" || tile.dataset.genres.split("|").indexOf(genre.value) >= 0) &&
+        (played.value === "" || (played.value === "played") === (playtime > 0));
+      tile.hidden = !visible;
+      if (visible) {
+        shown++;
+      }
+    });
+    count.textContent = shown + " of " + tiles.length + " games";
+
+    var key = sort.value;
+    tiles.slice().sort(function (first, second) {
+      if (key === "name") {
+        return first.dataset.name.localeCompare(second.dataset.name);
+      }
+      return Number(second.dataset[key]) - Number(first.dataset[key]);
+    }).forEach(function (tile) {
+      grid.appendChild(tile);
+    });
+  }
+
+  [search, source, genre, played, sort].forEach(function (input) {
+    input.addEventListener("input", update);
+  });
+  document.getElementById("filters").addEventListener("submit", function (event) {
+    event.preventDefault();
+  });
+  update();
+})();
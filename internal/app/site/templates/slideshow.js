@@ -0,0 +1,35 @@
+// Shows the games like the desktop visualizer: a random game's cover over its blurred backgrounds, the background
+// changing several times before the next game.
+(function () {
+  var games = window.LIBRARY.filter(function (game) { return game.cover; });
+  var config = window.LIBRARY_CONFIG;
+  var background = document.getElementById("background");
+  var cover = document.getElementById("cover");
+  var caption = document.getElementById("caption");
+  var backgroundMillis = 1000 * config.imageSeconds / Math.max(config.backgroundTransitions, 1);
+
+  function showGame() {
+    var game = games[Math.floor(Math.random() * games.length)];
+    cover.src = game.cover;
+    cover.alt = game.name;
+    caption.textContent = game.name;
+    caption.href = game.page;
+    var shown = 0;
+    (function showBackground() {
+      if (game.backgrounds.length > 0) {
+        var url = game.backgrounds[Math.floor(Math.random() * game.backgrounds.length)];
+        background.style.backgroundImage = "url('" + url + "')";
+      } else {
+        background.style.backgroundImage = "none";
+      }
+      shown++;
+      setTimeout(shown <= config.backgroundTransitions ? showBackground : showGame, backgroundMillis);
+    })();
+  }
+
+  if (games.length > 0) {
+    showGame();
+  } else {
+    caption.textContent = "No games with covers";
+  }
+})();
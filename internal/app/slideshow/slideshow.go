@@ -0,0 +1,263 @@
+package slideshow
+
+import (
+	"strconv"
+	"sync"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+type CommandKind int
+
+const (
+	Next CommandKind = iota + 1
+	Previous
+	Pause
+	Resume
+	TogglePause
+	ShowGame
+	SetFilter
+)
+
+// Command changes what the slideshow shows. ShowGame commands carry the key of the game to show, SetFilter commands
+// the library filter expression to pick the games by.
+type Command struct {
+	Kind    CommandKind
+	GameKey string
+	Filter  string
+}
+
+// Event tells what the slideshow shows right now, sent each time the game or its background changes and when the
+// slideshow is paused or resumed.
+type Event struct {
+	GameKey    string    `json:"game-key"`
+	Name       string    `json:"name"`
+	Source     string    `json:"source"`
+	SourceId   string    `json:"source-id"`
+	ArtworkId  string    `json:"artwork-id"`
+	ArtworkUrl string    `json:"artwork-url"`
+	Paused     bool      `json:"paused"`
+	Filter     string    `json:"filter"`
+	Time       time.Time `json:"time"`
+}
+
+// NewEvent is the event of the game shown with the artwork.
+func NewEvent(game domain.ClientGame, artwork domain.GameArtwork) Event {
+	artworkId := artwork.ArtworkId
+	if artworkId == "" && artwork.Id != 0 {
+		artworkId = strconv.Itoa(artwork.Id)
+	}
+	return Event{
+		GameKey:    game.Key(),
+		Name:       game.Name,
+		Source:     game.Source.String(),
+		SourceId:   game.SourceId,
+		ArtworkId:  artworkId,
+		ArtworkUrl: artwork.Url(),
+		Time:       time.Now(),
+	}
+}
+
+// Controller lets remote controls steer the slideshow. The slideshow waits between its images with Wait, which returns
+// early for the commands changing the game, and reports what it shows with Shown. Remote controls send commands and
+// subscribe to the events.
+type Controller struct {
+	commands    chan Command
+	mutex       sync.Mutex
+	paused      bool
+	filter      string
+	current     Event
+	shown       bool
+	history     []string
+	historySize int
+	subscribers map[chan Event]bool
+	followers   []*Controller
+}
+
+// New creates a controller remembering the last historySize games for Previous.
+func New(historySize int) *Controller {
+	return &Controller{
+		commands:    make(chan Command, 16),
+		historySize: historySize,
+		subscribers: make(map[chan Event]bool),
+	}
+}
+
+// Send queues the command for the slideshow. Commands sent while the queue is full are dropped, the slideshow is not
+// keeping up with them anyway.
+func (controller *Controller) Send(command Command) {
+	select {
+	case controller.commands <- command:
+	default:
+	}
+}
+
+// Wait waits for the duration, not counting the time the slideshow is paused. It returns early with the command when a
+// command changes the game, and false when the time is up.
+func (controller *Controller) Wait(duration time.Duration) (Command, bool) {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	deadline := time.Now().Add(duration)
+	remaining := duration
+	if controller.isPaused() {
+		stopTimer(timer)
+	}
+	for {
+		var timeUp <-chan time.Time
+		if !controller.isPaused() {
+			timeUp = timer.C
+		}
+		select {
+		case <-timeUp:
+			return Command{}, false
+		case command := <-controller.commands:
+			pause := command.Kind == Pause || (command.Kind == TogglePause && !controller.isPaused())
+			resume := command.Kind == Resume || (command.Kind == TogglePause && controller.isPaused())
+			switch {
+			case pause && !controller.isPaused():
+				stopTimer(timer)
+				remaining = time.Until(deadline)
+				controller.setPaused(true)
+				controller.sendToFollowers(Command{Kind: Pause})
+			case resume && controller.isPaused():
+				deadline = time.Now().Add(remaining)
+				stopTimer(timer)
+				timer.Reset(remaining)
+				controller.setPaused(false)
+				controller.sendToFollowers(Command{Kind: Resume})
+			case command.Kind == Next || command.Kind == Previous || command.Kind == ShowGame || command.Kind == SetFilter:
+				return command, true
+			}
+		}
+	}
+}
+
+// AddFollower makes the follower pause and resume with the controller, for slideshows shown side by side that are
+// paused as one.
+func (controller *Controller) AddFollower(follower *Controller) {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+	controller.followers = append(controller.followers, follower)
+}
+
+func (controller *Controller) sendToFollowers(command Command) {
+	controller.mutex.Lock()
+	followers := controller.followers
+	controller.mutex.Unlock()
+	for _, follower := range followers {
+		follower.Send(command)
+	}
+}
+
+// stopTimer stops the timer and drops a time it already sent, so it can be reset.
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
+func (controller *Controller) isPaused() bool {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+	return controller.paused
+}
+
+// Paused tells if the slideshow is paused, for the parts of it not waiting with Wait.
+func (controller *Controller) Paused() bool {
+	return controller.isPaused()
+}
+
+func (controller *Controller) setPaused(paused bool) {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+	controller.paused = paused
+	if controller.shown {
+		controller.current.Paused = paused
+		controller.current.Time = time.Now()
+		controller.publish(controller.current)
+	}
+}
+
+// SetFilter records the library filter expression the slideshow picks the games by, it is sent along with the events.
+func (controller *Controller) SetFilter(expression string) {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+	controller.filter = expression
+	if controller.shown {
+		controller.current.Filter = expression
+		controller.current.Time = time.Now()
+		controller.publish(controller.current)
+	}
+}
+
+// Filter returns the library filter expression the slideshow picks the games by.
+func (controller *Controller) Filter() string {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+	return controller.filter
+}
+
+// Shown records what the slideshow shows and sends it to the subscribers.
+func (controller *Controller) Shown(event Event) {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+	event.Paused = controller.paused
+	event.Filter = controller.filter
+	controller.current = event
+	controller.shown = true
+	if len(controller.history) == 0 || controller.history[len(controller.history)-1] != event.GameKey {
+		controller.history = append(controller.history, event.GameKey)
+		if len(controller.history) > controller.historySize {
+			controller.history = controller.history[1:]
+		}
+	}
+	controller.publish(event)
+}
+
+// PreviousGame steps back in the history and returns the key of the game shown before the current one, false at the
+// start of the history.
+func (controller *Controller) PreviousGame() (string, bool) {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+	if len(controller.history) < 2 {
+		return "", false
+	}
+	controller.history = controller.history[:len(controller.history)-1]
+	return controller.history[len(controller.history)-1], true
+}
+
+// Current returns what the slideshow shows, false before it showed anything.
+func (controller *Controller) Current() (Event, bool) {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+	return controller.current, controller.shown
+}
+
+// Subscribe returns a channel receiving the events until the returned cancel function is called. A subscriber not
+// reading its events misses them rather than holding up the slideshow.
+func (controller *Controller) Subscribe() (<-chan Event, func()) {
+	events := make(chan Event, 8)
+	controller.mutex.Lock()
+	controller.subscribers[events] = true
+	controller.mutex.Unlock()
+	return events, func() {
+		controller.mutex.Lock()
+		defer controller.mutex.Unlock()
+		if controller.subscribers[events] {
+			delete(controller.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+func (controller *Controller) publish(event Event) {
+	for subscriber := range controller.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
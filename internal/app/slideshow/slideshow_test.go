@@ -0,0 +1,159 @@
+package slideshow
+
+import (
+	"testing"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+func TestWaitTimesOut(t *testing.T) {
+	controller := New(10)
+	started := time.Now()
+	if command, interrupted := controller.Wait(30 * time.Millisecond); interrupted {
+		t.Fatalf("Wait without commands was interrupted by %+v", command)
+	}
+	if elapsed := time.Since(started); elapsed < 30*time.Millisecond {
+		t.Errorf("Wait returned after %v, want at least 30ms", elapsed)
+	}
+}
+
+func TestWaitReturnsGameCommands(t *testing.T) {
+	tests := []Command{
+		{Kind: Next},
+		{Kind: Previous},
+		{Kind: ShowGame, GameKey: "Steam620"},
+		{Kind: SetFilter, Filter: "favourite"},
+	}
+	for _, sent := range tests {
+		controller := New(10)
+		controller.Send(sent)
+		started := time.Now()
+		command, interrupted := controller.Wait(time.Minute)
+		if !interrupted || command != sent {
+			t.Errorf("Wait = %+v %v, want %+v true", command, interrupted, sent)
+		}
+		if elapsed := time.Since(started); elapsed > time.Second {
+			t.Errorf("Wait for %+v returned after %v, want right away", sent, elapsed)
+		}
+	}
+}
+
+func TestWaitDoesNotCountPausedTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		pause  Command
+		resume Command
+	}{
+		{"pause and resume", Command{Kind: Pause}, Command{Kind: Resume}},
+		{"toggle pause", Command{Kind: TogglePause}, Command{Kind: TogglePause}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := New(10)
+			controller.Send(test.pause)
+			time.AfterFunc(100*time.Millisecond, func() {
+				if !controller.Paused() {
+					t.Error("Paused = false after the pause command, want true")
+				}
+				controller.Send(test.resume)
+			})
+			started := time.Now()
+			if command, interrupted := controller.Wait(50 * time.Millisecond); interrupted {
+				t.Fatalf("Wait was interrupted by %+v, want pause and resume to be handled by Wait", command)
+			}
+			if elapsed := time.Since(started); elapsed < 150*time.Millisecond {
+				t.Errorf("Wait of 50ms paused for 100ms returned after %v, want at least 150ms", elapsed)
+			}
+			if controller.Paused() {
+				t.Error("Paused = true after the resume command, want false")
+			}
+		})
+	}
+}
+
+func TestWaitStaysPaused(t *testing.T) {
+	controller := New(10)
+	controller.Send(Command{Kind: Pause})
+	time.AfterFunc(100*time.Millisecond, func() {
+		controller.Send(Command{Kind: Next})
+	})
+	started := time.Now()
+	command, interrupted := controller.Wait(10 * time.Millisecond)
+	if !interrupted || command.Kind != Next {
+		t.Fatalf("Wait = %+v %v, want the next command", command, interrupted)
+	}
+	if elapsed := time.Since(started); elapsed < 100*time.Millisecond {
+		t.Errorf("Paused Wait returned after %v, want it to wait for the next command", elapsed)
+	}
+	// The next Wait is still paused, the pause lasts until resumed
+	controller.Send(Command{Kind: Next})
+	if command, _ := controller.Wait(10 * time.Millisecond); command.Kind != Next {
+		t.Errorf("Wait after the pause = %+v, want the next command", command)
+	}
+	if !controller.Paused() {
+		t.Error("Paused = false, want the pause to last over Wait calls")
+	}
+}
+
+func TestPauseEvents(t *testing.T) {
+	controller := New(10)
+	events, cancel := controller.Subscribe()
+	defer cancel()
+	controller.Shown(NewEvent(domain.ClientGame{Name: "Portal 2", Source: domain.Steam, SourceId: "620"}, domain.GameArtwork{}))
+	controller.Send(Command{Kind: Pause})
+	controller.Send(Command{Kind: Next})
+	controller.Wait(time.Minute)
+
+	for _, wantPaused := range []bool{false, true} {
+		select {
+		case event := <-events:
+			if event.GameKey != "Steam620" || event.Paused != wantPaused {
+				t.Errorf("event = %s paused %v, want Steam620 paused %v", event.GameKey, event.Paused, wantPaused)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("No event received")
+		}
+	}
+}
+
+func TestPreviousGame(t *testing.T) {
+	controller := New(2)
+	for _, sourceId := range []string{"10", "20", "20", "30"} {
+		controller.Shown(NewEvent(domain.ClientGame{Source: domain.Steam, SourceId: sourceId}, domain.GameArtwork{}))
+	}
+	// The history keeps the last 2 games, a game shown with several backgrounds once
+	if key, found := controller.PreviousGame(); !found || key != "Steam20" {
+		t.Errorf("PreviousGame = %s %v, want Steam20 true", key, found)
+	}
+	if key, found := controller.PreviousGame(); found {
+		t.Errorf("PreviousGame at the start of the history = %s, want none", key)
+	}
+}
+
+func TestFollowersPauseWithController(t *testing.T) {
+	leader, follower := New(10), New(10)
+	leader.AddFollower(follower)
+	leader.Send(Command{Kind: TogglePause})
+	leader.Send(Command{Kind: Next})
+	leader.Wait(time.Minute)
+	follower.Send(Command{Kind: Next})
+	follower.Wait(time.Minute)
+	if !follower.Paused() {
+		t.Error("Follower Paused = false after the controller paused, want true")
+	}
+
+	leader.Send(Command{Kind: Resume})
+	leader.Wait(10 * time.Millisecond)
+	follower.Wait(10 * time.Millisecond)
+	if follower.Paused() {
+		t.Error("Follower Paused = true after the controller resumed, want false")
+	}
+
+	// Followers pause on their own without pausing the controller
+	follower.Send(Command{Kind: Pause})
+	follower.Send(Command{Kind: Next})
+	follower.Wait(time.Minute)
+	if leader.Wait(10 * time.Millisecond); leader.Paused() {
+		t.Error("Controller Paused = true after a follower paused, want false")
+	}
+}
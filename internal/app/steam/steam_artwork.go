@@ -0,0 +1,132 @@
+package steam
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"strconv"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+const steamCdnUrl = "https://cdn.cloudflare.steamstatic.com/steam/apps/"
+
+// libraryAsset is an image the Steam client library shows for every app, served from the Steam CDN by app id.
+type libraryAsset struct {
+	fileName     string
+	artType      domain.ArtworkType
+	width        int
+	height       int
+	alphaChannel bool
+}
+
+var libraryAssets = []libraryAsset{
+	{fileName: "library_600x900.jpg", artType: domain.Cover, width: 600, height: 900},
+	{fileName: "library_hero.jpg", artType: domain.Hero, width: 3840, height: 1240},
+	{fileName: "logo.png", artType: domain.Logo, alphaChannel: true},
+}
+
+// GetGameArtworks returns the Steam store and library images of a Steam game: the library capsule as cover, the
+// library hero and logo, the store page background, the screenshots and the store header. The store images of games
+// synced from Steam came with the store data of the sync and are not fetched again.
+func GetGameArtworks(clientGame domain.ClientGame) ([]domain.GameArtwork, error) {
+	if clientGame.Source != domain.Steam {
+		return nil, nil
+	}
+	appId, convErr := strconv.Atoi(clientGame.SourceId)
+	if convErr != nil {
+		return nil, convErr
+	}
+
+	fmt.Println("Fetching Steam artworks ...")
+	artworks := getLibraryArtworks(appId)
+	if storeArtworks := getKnownStoreArtworks(clientGame); len(storeArtworks) > 0 {
+		fmt.Println("Fetching Steam artworks success!")
+		return append(artworks, storeArtworks...), nil
+	}
+
+	storeGameData, storeError := getStoreGame(appId)
+	if storeError != nil {
+		fmt.Println("Fetching Steam artworks failed!")
+		return nil, storeError
+	}
+	if !storeGameData.Success {
+		// Games removed from the store still have their library images
+		return artworks, nil
+	}
+	fmt.Println("Fetching Steam artworks success!")
+	return append(artworks, getStoreArtworks(storeGameData.Data)...), nil
+}
+
+// getStoreArtworks returns the store page background, the screenshots and the store header of the store data.
+func getStoreArtworks(storeGame game) []domain.GameArtwork {
+	var artworks []domain.GameArtwork
+	if storeGame.Background != "" {
+		artworks = append(artworks, newSteamArtwork(storeGame.Background, domain.Artwork, 0, 0))
+	}
+	for _, storeScreenshot := range storeGame.Screenshots {
+		screenshotArtwork := newSteamArtwork(storeScreenshot.PathFull, domain.ScreenShot, 0, 0)
+		screenshotArtwork.Id = storeScreenshot.Id
+		artworks = append(artworks, screenshotArtwork)
+	}
+	if storeGame.HeaderImage != "" {
+		artworks = append(artworks, newSteamArtwork(storeGame.HeaderImage, domain.Artwork, 460, 215))
+	}
+	return artworks
+}
+
+// getKnownStoreArtworks returns the Steam artworks the game already has that are not library assets, those are checked
+// on the CDN each time.
+func getKnownStoreArtworks(clientGame domain.ClientGame) []domain.GameArtwork {
+	var storeArtworks []domain.GameArtwork
+	for _, artwork := range clientGame.Artworks {
+		if artwork.GetProvider() == domain.SteamProvider && !isLibraryAsset(artwork) {
+			storeArtworks = append(storeArtworks, artwork)
+		}
+	}
+	return storeArtworks
+}
+
+func isLibraryAsset(artwork domain.GameArtwork) bool {
+	for _, asset := range libraryAssets {
+		if strings.HasSuffix(artwork.ImageUrl, "/"+asset.fileName) && strings.HasPrefix(artwork.ImageUrl, steamCdnUrl) {
+			return true
+		}
+	}
+	return false
+}
+
+// getLibraryArtworks returns the library assets the CDN has for the app. Older apps often have none.
+func getLibraryArtworks(appId int) []domain.GameArtwork {
+	var artworks []domain.GameArtwork
+	for _, asset := range libraryAssets {
+		assetUrl := steamCdnUrl + strconv.Itoa(appId) + "/" + asset.fileName
+		if checkAssetExists(assetUrl) == nil {
+			assetArtwork := newSteamArtwork(assetUrl, asset.artType, asset.width, asset.height)
+			assetArtwork.AlphaChannel = asset.alphaChannel
+			artworks = append(artworks, assetArtwork)
+		}
+	}
+	return artworks
+}
+
+func checkAssetExists(assetUrl string) error {
+	assetResp, assetError := resty.New().R().Head(assetUrl)
+	if assetError != nil {
+		return assetError
+	}
+	if assetResp.StatusCode() != 200 {
+		return errors.New("Steam asset not found " + assetUrl + " Response Code: " + strconv.Itoa(assetResp.StatusCode()))
+	}
+	return nil
+}
+
+func newSteamArtwork(imageUrl string, artType domain.ArtworkType, width int, height int) domain.GameArtwork {
+	return domain.GameArtwork{
+		Type:     artType,
+		Width:    width,
+		Height:   height,
+		Provider: domain.SteamProvider,
+		ImageUrl: imageUrl,
+	}
+}
@@ -23,8 +23,13 @@ type userOwnedGameList struct {
 	Games     []userOwnedGame `json:"games"`
 }
 type userOwnedGame struct {
-	AppId           int `json:"appid"`
-	PlaytimeForever int `json:"playtime_forever"`
+	AppId           int   `json:"appid"`
+	PlaytimeForever int   `json:"playtime_forever"`
+	Playtime2Weeks  int   `json:"playtime_2weeks"`
+	PlaytimeWindows int   `json:"playtime_windows_forever"`
+	PlaytimeMac     int   `json:"playtime_mac_forever"`
+	PlaytimeLinux   int   `json:"playtime_linux_forever"`
+	LastPlayed      int64 `json:"rtime_last_played"`
 }
 
 type gameData struct {
@@ -33,28 +38,38 @@ type gameData struct {
 }
 
 type game struct {
-	Type        string   `mapstructure:"type"`
-	Name        string   `mapstructure:"name"`
-	Description string   `mapstructure:"short_description"`
-	Free        bool     `mapstructure:"is_free"`
-	Developers  []string `mapstructure:"developers"`
-	AppId       int      `mapstructure:"steam_appid"`
+	Type        string       `mapstructure:"type"`
+	Name        string       `mapstructure:"name"`
+	Description string       `mapstructure:"short_description"`
+	Free        bool         `mapstructure:"is_free"`
+	Developers  []string     `mapstructure:"developers"`
+	AppId       int          `mapstructure:"steam_appid"`
+	HeaderImage string       `mapstructure:"header_image"`
+	Background  string       `mapstructure:"background_raw"`
+	Screenshots []screenshot `mapstructure:"screenshots"`
 }
 
-func GetGames(clientGames []domain.ClientGame, props properties.Properties) ([]domain.ClientGame, error) {
+type screenshot struct {
+	Id       int    `mapstructure:"id"`
+	PathFull string `mapstructure:"path_full"`
+}
+
+// GetGames returns the owned games missing from clientGames, and the already processed client games with their
+// playtime refreshed from the user's Steam account.
+func GetGames(clientGames []domain.ClientGame, props properties.Properties) ([]domain.ClientGame, []domain.ClientGame, error) {
 	fmt.Println("Fetching unprocessed Steam games ...")
 	userOwnedGames, userError := getUserOwnedGames(props)
 	if userError != nil {
 		fmt.Println("Fetching Steam games failed!")
-		return nil, userError
+		return nil, nil, userError
 	} else {
 		unprocessedGames := findUnprocessedGames(clientGames, userOwnedGames)
 		storeGames, err := getStoreGames(unprocessedGames)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		fmt.Println("Fetching unprocessed Steam games success!")
-		return convertGames(storeGames), nil
+		return convertGames(storeGames, unprocessedGames), refreshGames(clientGames, userOwnedGames), nil
 	}
 }
 
@@ -91,7 +106,42 @@ func findUnprocessedGames(clientGames []domain.ClientGame, userOwnedGames []user
 	return unprocessedGames
 }
 
-func convertGames(steamGames []game) []domain.ClientGame {
+func refreshGames(clientGames []domain.ClientGame, userOwnedGames []userOwnedGame) []domain.ClientGame {
+	ownedGamesById := make(map[string]userOwnedGame)
+	for _, steamGame := range userOwnedGames {
+		ownedGamesById[strconv.Itoa(steamGame.AppId)] = steamGame
+	}
+	refreshedGames := make([]domain.ClientGame, 0)
+	for _, clientGame := range clientGames {
+		if clientGame.Source != domain.Steam {
+			continue
+		}
+		if steamGame, found := ownedGamesById[clientGame.SourceId]; found {
+			setPlaytime(&clientGame, steamGame)
+			refreshedGames = append(refreshedGames, clientGame)
+		}
+	}
+	return refreshedGames
+}
+
+func setPlaytime(clientGame *domain.ClientGame, steamGame userOwnedGame) {
+	clientGame.PlaytimeForever = steamGame.PlaytimeForever
+	clientGame.Playtime2Weeks = steamGame.Playtime2Weeks
+	clientGame.PlaytimeWindows = steamGame.PlaytimeWindows
+	clientGame.PlaytimeMac = steamGame.PlaytimeMac
+	clientGame.PlaytimeLinux = steamGame.PlaytimeLinux
+	clientGame.LastPlayed = time.Time{}
+	// Steam reports 0 for games that were never played
+	if steamGame.LastPlayed > 0 {
+		clientGame.LastPlayed = time.Unix(steamGame.LastPlayed, 0)
+	}
+}
+
+func convertGames(steamGames []game, ownedGames []userOwnedGame) []domain.ClientGame {
+	ownedGamesById := make(map[int]userOwnedGame)
+	for _, ownedGame := range ownedGames {
+		ownedGamesById[ownedGame.AppId] = ownedGame
+	}
 	var clientGames []domain.ClientGame
 	for _, steamGame := range steamGames {
 		x := domain.Steam
@@ -101,6 +151,9 @@ func convertGames(steamGames []game) []domain.ClientGame {
 		clientGame.SourceId = strconv.Itoa(steamGame.AppId)
 		clientGame.Description = steamGame.Description
 		clientGame.Developers = steamGame.Developers
+		// The store images come with the store data, so the Steam artwork provider does not need to fetch it again
+		clientGame.Artworks = getStoreArtworks(steamGame)
+		setPlaytime(&clientGame, ownedGamesById[steamGame.AppId])
 		clientGames = append(clientGames, clientGame)
 	}
 	return clientGames
@@ -0,0 +1,218 @@
+package steamgriddb
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/magiconair/properties"
+	"net/url"
+	"strconv"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+// assetType is one of the SteamGridDB image kinds with the style and dimension preferences configured for it.
+type assetType struct {
+	path       string
+	artType    domain.ArtworkType
+	styles     string
+	dimensions string
+}
+
+type Config struct {
+	ApiUrl     string
+	ApiKey     string
+	Assets     []assetType
+	Types      string
+	MaxPerType int
+}
+
+// LoadConfig reads the API key from the secret properties and the API URL and image preferences from the main
+// properties. Styles and dimensions are comma separated lists passed as is to SteamGridDB, empty lists accept all.
+func LoadConfig(props properties.Properties, secretProps properties.Properties) Config {
+	allAssets := map[string]assetType{
+		"grids":  {path: "grids", artType: domain.Cover, styles: props.GetString("visualizer.steamgriddb.grid.styles", ""), dimensions: props.GetString("visualizer.steamgriddb.grid.dimensions", "600x900")},
+		"heroes": {path: "heroes", artType: domain.Hero, styles: props.GetString("visualizer.steamgriddb.hero.styles", ""), dimensions: props.GetString("visualizer.steamgriddb.hero.dimensions", "")},
+		"logos":  {path: "logos", artType: domain.Logo, styles: props.GetString("visualizer.steamgriddb.logo.styles", ""), dimensions: ""},
+		"icons":  {path: "icons", artType: domain.Icon, styles: props.GetString("visualizer.steamgriddb.icon.styles", ""), dimensions: props.GetString("visualizer.steamgriddb.icon.dimensions", "")},
+	}
+	var assets []assetType
+	for _, assetName := range strings.Split(props.GetString("visualizer.steamgriddb.assets", "grids,heroes,logos"), ",") {
+		if asset, found := allAssets[strings.TrimSpace(assetName)]; found {
+			assets = append(assets, asset)
+		}
+	}
+	return Config{
+		ApiUrl:     strings.TrimSuffix(props.GetString("visualizer.steamgriddb.api.url", "https://www.steamgriddb.com/api/v2"), "/"),
+		ApiKey:     secretProps.GetString("steamgriddb.client.key", ""),
+		Assets:     assets,
+		Types:      props.GetString("visualizer.steamgriddb.types", "static"),
+		MaxPerType: props.GetInt("visualizer.steamgriddb.max.per.type", 3),
+	}
+}
+
+type imageResponse struct {
+	Success bool    `json:"success"`
+	Data    []image `json:"data"`
+}
+
+type image struct {
+	Id     int      `json:"id"`
+	Score  int      `json:"score"`
+	Style  string   `json:"style"`
+	Width  int      `json:"width"`
+	Height int      `json:"height"`
+	Mime   string   `json:"mime"`
+	Url    string   `json:"url"`
+	Tags   []string `json:"tags"`
+}
+
+type searchResponse struct {
+	Success bool         `json:"success"`
+	Data    []searchGame `json:"data"`
+}
+
+type searchGame struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetGameArtworks returns the SteamGridDB grids, heroes, logos and icons of the game. Steam games are looked up by app
+// id, other games or Steam games SteamGridDB does not know by app id are looked up by name.
+func GetGameArtworks(clientGame domain.ClientGame, config Config) ([]domain.GameArtwork, error) {
+	if config.ApiKey == "" {
+		return nil, errors.New("No SteamGridDB key configured in steamgriddb.client.key")
+	}
+	fmt.Println("Fetching SteamGridDB artworks ...")
+
+	var artworks []domain.GameArtwork
+	if clientGame.Source == domain.Steam {
+		steamArtworks, steamErr := getArtworks("steam/"+clientGame.SourceId, config)
+		if steamErr != nil {
+			fmt.Println("Fetching SteamGridDB artworks failed!")
+			return nil, steamErr
+		}
+		artworks = steamArtworks
+	}
+
+	if len(artworks) == 0 {
+		gameId, searchErr := searchGameId(clientGame.Name, config)
+		if searchErr != nil {
+			fmt.Println("Fetching SteamGridDB artworks failed! Failed on game search")
+			return nil, searchErr
+		}
+		if gameId == 0 {
+			return nil, nil
+		}
+		nameArtworks, nameErr := getArtworks("game/"+strconv.Itoa(gameId), config)
+		if nameErr != nil {
+			fmt.Println("Fetching SteamGridDB artworks failed!")
+			return nil, nameErr
+		}
+		artworks = nameArtworks
+	}
+	fmt.Println("Fetching SteamGridDB artworks success!")
+	return artworks, nil
+}
+
+func getArtworks(gamePath string, config Config) ([]domain.GameArtwork, error) {
+	var artworks []domain.GameArtwork
+	for _, asset := range config.Assets {
+		images, imagesErr := getImages(asset, gamePath, config)
+		if imagesErr != nil {
+			return nil, imagesErr
+		}
+		// SteamGridDB returns the images with the best score first
+		if config.MaxPerType > 0 && len(images) > config.MaxPerType {
+			images = images[:config.MaxPerType]
+		}
+		for _, gridImage := range images {
+			artworks = append(artworks, convertImage(gridImage, asset))
+		}
+	}
+	return artworks, nil
+}
+
+func getImages(asset assetType, gamePath string, config Config) ([]image, error) {
+	queryParams := map[string]string{}
+	if asset.styles != "" {
+		queryParams["styles"] = asset.styles
+	}
+	if asset.dimensions != "" {
+		queryParams["dimensions"] = asset.dimensions
+	}
+	if config.Types != "" {
+		queryParams["types"] = config.Types
+	}
+	imagesResp, imagesErr := resty.New().R().
+		SetAuthToken(config.ApiKey).
+		SetQueryParams(queryParams).
+		SetResult(imageResponse{}).
+		Get(config.ApiUrl + "/" + asset.path + "/" + gamePath)
+	if imagesErr != nil {
+		return nil, imagesErr
+	}
+	// Unknown games are answered with not found rather than an empty list
+	if imagesResp.StatusCode() == 404 {
+		return nil, nil
+	}
+	if imagesResp.StatusCode() < 200 || imagesResp.StatusCode() > 299 {
+		return nil, errors.New("Fetching SteamGridDB " + asset.path + " failed! Response Code: " + strconv.Itoa(imagesResp.StatusCode()) + " Response Message: " + imagesResp.String())
+	}
+	return imagesResp.Result().(*imageResponse).Data, nil
+}
+
+func searchGameId(name string, config Config) (int, error) {
+	searchResp, searchErr := resty.New().R().
+		SetAuthToken(config.ApiKey).
+		SetResult(searchResponse{}).
+		Get(config.ApiUrl + "/search/autocomplete/" + url.PathEscape(name))
+	if searchErr != nil {
+		return 0, searchErr
+	}
+	if searchResp.StatusCode() < 200 || searchResp.StatusCode() > 299 {
+		return 0, errors.New("Searching SteamGridDB games failed! Response Code: " + strconv.Itoa(searchResp.StatusCode()) + " Response Message: " + searchResp.String())
+	}
+	searchResults := searchResp.Result().(*searchResponse).Data
+	for _, result := range searchResults {
+		if strings.EqualFold(result.Name, name) {
+			return result.Id, nil
+		}
+	}
+	if len(searchResults) > 0 {
+		return searchResults[0].Id, nil
+	}
+	return 0, nil
+}
+
+func convertImage(gridImage image, asset assetType) domain.GameArtwork {
+	artType := asset.artType
+	// Grids come as portrait covers and as wide capsules, the wide ones are used as backgrounds
+	if artType == domain.Cover && gridImage.Width >= gridImage.Height {
+		artType = domain.Artwork
+	}
+	return domain.GameArtwork{
+		Id:           gridImage.Id,
+		Type:         artType,
+		Width:        gridImage.Width,
+		Height:       gridImage.Height,
+		Animated:     isAnimated(gridImage),
+		AlphaChannel: gridImage.Mime == "image/png",
+		Provider:     domain.SteamGridDbProvider,
+		ImageUrl:     gridImage.Url,
+	}
+}
+
+// isAnimated tells animated images by the animated tag or style SteamGridDB gives them. WebP is used for static images
+// as well, so the mime type alone does not tell.
+func isAnimated(gridImage image) bool {
+	if strings.EqualFold(gridImage.Style, "animated") || gridImage.Mime == "image/gif" {
+		return true
+	}
+	for _, tag := range gridImage.Tags {
+		if strings.EqualFold(tag, "animated") {
+			return true
+		}
+	}
+	return false
+}
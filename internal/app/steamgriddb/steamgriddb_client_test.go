@@ -0,0 +1,112 @@
+package steamgriddb
+
+import (
+	"github.com/magiconair/properties"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+// newTestServer answers like SteamGridDB: grids and logos of Steam app 620, nothing for app 400 by id and the grids of
+// game 42 found by name.
+func newTestServer(t *testing.T) (*httptest.Server, *[]string) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests = append(requests, request.URL.Path+"?"+request.URL.RawQuery)
+		if request.Header.Get("Authorization") != "Bearer test-key" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		switch request.URL.Path {
+		case "/grids/steam/620":
+			writer.Write([]byte(`{"success":true,"data":[
+				{"id":1,"style":"alternate","width":600,"height":900,"mime":"image/png","url":"http://grid/1.png"},
+				{"id":2,"style":"alternate","width":920,"height":430,"mime":"image/jpeg","url":"http://grid/2.jpg"},
+				{"id":3,"style":"alternate","width":600,"height":900,"mime":"image/webp","url":"http://grid/3.webp","tags":["animated"]},
+				{"id":4,"style":"alternate","width":600,"height":900,"mime":"image/webp","url":"http://grid/4.webp"}]}`))
+		case "/logos/steam/620":
+			writer.Write([]byte(`{"success":true,"data":[{"id":5,"style":"official","width":800,"height":300,"mime":"image/png","url":"http://logo/5.png"}]}`))
+		case "/search/autocomplete/Half-Life":
+			writer.Write([]byte(`{"success":true,"data":[{"id":41,"name":"Half-Life 2"},{"id":42,"name":"half-life"}]}`))
+		case "/grids/game/42":
+			writer.Write([]byte(`{"success":true,"data":[{"id":6,"width":600,"height":900,"mime":"image/jpeg","url":"http://grid/6.jpg"}]}`))
+		case "/logos/game/42":
+			writer.Write([]byte(`{"success":true,"data":[]}`))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+			writer.Write([]byte(`{"success":false,"errors":["Game not found"]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestConfig(apiUrl string) Config {
+	props := properties.NewProperties()
+	props.Set("visualizer.steamgriddb.api.url", apiUrl+"/")
+	props.Set("visualizer.steamgriddb.assets", "grids,logos")
+	props.Set("visualizer.steamgriddb.max.per.type", "4")
+	secretProps := properties.NewProperties()
+	secretProps.Set("steamgriddb.client.key", "test-key")
+	return LoadConfig(*props, *secretProps)
+}
+
+func TestGetGameArtworksBySteamAppId(t *testing.T) {
+	server, requests := newTestServer(t)
+	artworks, err := GetGameArtworks(domain.ClientGame{Name: "Portal 2", Source: domain.Steam, SourceId: "620"}, newTestConfig(server.URL))
+	if err != nil {
+		t.Fatalf("GetGameArtworks failed: %v", err)
+	}
+	want := []struct {
+		id       int
+		artType  domain.ArtworkType
+		animated bool
+	}{
+		{1, domain.Cover, false},
+		{2, domain.Artwork, false},
+		{3, domain.Cover, true},
+		{4, domain.Cover, false},
+		{5, domain.Logo, false},
+	}
+	if len(artworks) != len(want) {
+		t.Fatalf("GetGameArtworks returned %d artworks, want %d", len(artworks), len(want))
+	}
+	for index, wanted := range want {
+		got := artworks[index]
+		if got.Id != wanted.id || got.Type != wanted.artType || got.Animated != wanted.animated {
+			t.Errorf("artwork %d = id %d type %v animated %v, want id %d type %v animated %v", index, got.Id, got.Type, got.Animated, wanted.id, wanted.artType, wanted.animated)
+		}
+		if got.GetProvider() != domain.SteamGridDbProvider {
+			t.Errorf("artwork %d provider = %v, want %v", index, got.GetProvider(), domain.SteamGridDbProvider)
+		}
+	}
+	if (*requests)[0] != "/grids/steam/620?dimensions=600x900&types=static" {
+		t.Errorf("first request = %s, want the configured dimensions and types", (*requests)[0])
+	}
+}
+
+func TestGetGameArtworksByName(t *testing.T) {
+	server, _ := newTestServer(t)
+	artworks, err := GetGameArtworks(domain.ClientGame{Name: "Half-Life", Source: domain.Steam, SourceId: "400"}, newTestConfig(server.URL))
+	if err != nil {
+		t.Fatalf("GetGameArtworks failed: %v", err)
+	}
+	if len(artworks) != 1 || artworks[0].Id != 6 {
+		t.Fatalf("GetGameArtworks = %+v, want the grid of the exact name match", artworks)
+	}
+}
+
+func TestGetGameArtworksErrors(t *testing.T) {
+	server, _ := newTestServer(t)
+	config := newTestConfig(server.URL)
+	config.ApiKey = ""
+	if _, err := GetGameArtworks(domain.ClientGame{Name: "Portal 2", Source: domain.Steam, SourceId: "620"}, config); err == nil {
+		t.Error("GetGameArtworks without key succeeded, want an error")
+	}
+	config.ApiKey = "wrong-key"
+	if _, err := GetGameArtworks(domain.ClientGame{Name: "Portal 2", Source: domain.Steam, SourceId: "620"}, config); err == nil {
+		t.Error("GetGameArtworks with a rejected key succeeded, want an error")
+	}
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tidwall/buntdb"
+	"strings"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+// stateKeyPrefix marks the DB keys holding visualizer state rather than games.
+const stateKeyPrefix = "state:"
+
+// Indexes ordering the games by their IGDB metadata, for GetGamesOrderedBy.
+const (
+	ReleaseDateIndex = "first_release_date"
+	RatingIndex      = "aggregated_rating"
+)
+
+// Store keeps the games with their metadata and artworks, and the visualizer state, in a buntdb file. Games are stored
+// as JSON by their key.
+type Store struct {
+	db *buntdb.DB
+}
+
+// Open opens the DB file, creating it when it does not exist yet.
+func Open(path string) (*Store, error) {
+	db, err := buntdb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	err = db.CreateIndex("source", "*", buntdb.IndexJSON("source"))
+	if err != nil {
+		fmt.Println(err)
+	}
+	err = db.CreateIndex("source_source_id", "*", buntdb.IndexJSON("source"), buntdb.IndexJSON("source-id"))
+	if err != nil {
+		fmt.Println(err)
+	}
+	err = db.CreateIndex("genres", "*", buntdb.IndexJSON("genres"))
+	if err != nil {
+		fmt.Println(err)
+	}
+	err = db.CreateIndex(ReleaseDateIndex, "*", buntdb.IndexJSON("first-release-date"))
+	if err != nil {
+		fmt.Println(err)
+	}
+	err = db.CreateIndex(RatingIndex, "*", buntdb.IndexJSON("aggregated-rating"))
+	if err != nil {
+		fmt.Println(err)
+	}
+	err = db.CreateIndex("franchise", "*", buntdb.IndexJSON("franchise"))
+	if err != nil {
+		fmt.Println(err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (store *Store) Close() error {
+	return store.db.Close()
+}
+
+func (store *Store) SaveGame(game domain.ClientGame) error {
+	return store.db.Update(func(tx *buntdb.Tx) error {
+		bytes, marshErr := json.Marshal(game)
+		if marshErr != nil {
+			return marshErr
+		}
+		_, _, setErr := tx.Set(game.Key(), string(bytes), nil)
+		return setErr
+	})
+}
+
+// GetGame returns the game stored by the key, buntdb.ErrNotFound when there is none.
+func (store *Store) GetGame(key string) (domain.ClientGame, error) {
+	game := domain.ClientGame{}
+	err := store.db.View(func(tx *buntdb.Tx) error {
+		value, getErr := tx.Get(key)
+		if getErr != nil {
+			return getErr
+		}
+		return json.Unmarshal([]byte(value), &game)
+	})
+	return game, err
+}
+
+// GetGames returns all stored games ordered by source.
+func (store *Store) GetGames() ([]domain.ClientGame, error) {
+	return store.GetGamesOrderedBy("source", false)
+}
+
+// GetGamesOrderedBy returns all stored games in the order of the index, e.g. RatingIndex, games without the indexed
+// value first unless descending.
+func (store *Store) GetGamesOrderedBy(index string, descending bool) ([]domain.ClientGame, error) {
+	ownedGames := make([]domain.ClientGame, 0)
+	err := store.db.View(func(tx *buntdb.Tx) error {
+		iterate := tx.Ascend
+		if descending {
+			iterate = tx.Descend
+		}
+		return iterate(index, func(key, value string) bool {
+			if strings.HasPrefix(key, stateKeyPrefix) {
+				return true
+			}
+			game := domain.ClientGame{}
+			json.Unmarshal([]byte(value), &game)
+			ownedGames = append(ownedGames, game)
+			return true
+		})
+	})
+	return ownedGames, err
+}
+
+// GetState returns visualizer state stored by key, such as the state of the game selection.
+func (store *Store) GetState(key string) (string, error) {
+	var value string
+	err := store.db.View(func(tx *buntdb.Tx) error {
+		var getErr error
+		value, getErr = tx.Get(stateKeyPrefix + key)
+		return getErr
+	})
+	return value, err
+}
+
+func (store *Store) SetState(key string, value string) error {
+	return store.db.Update(func(tx *buntdb.Tx) error {
+		_, _, setErr := tx.Set(stateKeyPrefix+key, value, nil)
+		return setErr
+	})
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"testing"
+	"time"
+	"vg-cover-screen-saver-go/internal/app/domain"
+)
+
+func TestGetGamesOrderedBy(t *testing.T) {
+	gameStore, openErr := Open(":memory:")
+	if openErr != nil {
+		t.Fatalf("Open failed: %v", openErr)
+	}
+	defer gameStore.Close()
+	games := []domain.ClientGame{
+		{Name: "Portal 2", Source: domain.Steam, SourceId: "620", AggregatedRating: 100, FirstReleaseDate: time.Date(2011, 4, 18, 0, 0, 0, 0, time.UTC)},
+		{Name: "Half-Life", Source: domain.Steam, SourceId: "70", AggregatedRating: 91.5, FirstReleaseDate: time.Date(1998, 11, 19, 0, 0, 0, 0, time.UTC)},
+		{Name: "Unknown", Source: domain.Steam, SourceId: "1"},
+	}
+	for _, game := range games {
+		if err := gameStore.SaveGame(game); err != nil {
+			t.Fatalf("SaveGame failed: %v", err)
+		}
+	}
+	gameStore.SetState("shuffle-bag", `["Steam620"]`)
+
+	tests := []struct {
+		index      string
+		descending bool
+		want       []string
+	}{
+		{ReleaseDateIndex, false, []string{"Unknown", "Half-Life", "Portal 2"}},
+		{ReleaseDateIndex, true, []string{"Portal 2", "Half-Life", "Unknown"}},
+		{RatingIndex, false, []string{"Unknown", "Half-Life", "Portal 2"}},
+		{RatingIndex, true, []string{"Portal 2", "Half-Life", "Unknown"}},
+	}
+	for _, test := range tests {
+		ordered, err := gameStore.GetGamesOrderedBy(test.index, test.descending)
+		if err != nil {
+			t.Fatalf("GetGamesOrderedBy(%s) failed: %v", test.index, err)
+		}
+		var names []string
+		for _, game := range ordered {
+			names = append(names, game.Name)
+		}
+		if len(names) != len(test.want) || names[0] != test.want[0] || names[1] != test.want[1] || names[2] != test.want[2] {
+			t.Errorf("GetGamesOrderedBy(%s, descending %v) = %v, want %v", test.index, test.descending, names, test.want)
+		}
+	}
+}
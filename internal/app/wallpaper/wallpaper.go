@@ -0,0 +1,144 @@
+package wallpaper
+
+import (
+	"errors"
+	"github.com/magiconair/properties"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setterCommands are the command templates of the known wallpaper setters. {path} is replaced by the absolute path of
+// the wallpaper, {uri} by its file URI.
+var setterCommands = map[string][]string{
+	"gsettings": {
+		"gsettings set org.gnome.desktop.background picture-uri {uri}",
+		"gsettings set org.gnome.desktop.background picture-uri-dark {uri}",
+	},
+	"feh":    {"feh --no-fehbg --bg-fill {path}"},
+	"swaybg": {"swaybg --mode fill --image {path}"},
+}
+
+// optionalCommands may fail without failing the setter: picture-uri-dark only exists since GNOME 42 and older GNOME
+// versions show picture-uri in dark mode as well.
+var optionalCommands = map[string]bool{
+	"gsettings set org.gnome.desktop.background picture-uri-dark {uri}": true,
+}
+
+// keptRunning are the setters showing the wallpaper as long as they run, they are replaced by the next one rather than
+// waited on.
+var keptRunning = map[string]bool{
+	"swaybg": true,
+}
+
+// Config is the schedule of the wallpaper rotation and the setter applying the wallpapers. Width and height of 0 take
+// the resolution of the monitor.
+type Config struct {
+	Setter    string
+	Command   string
+	Interval  time.Duration
+	Directory string
+	Width     int
+	Height    int
+}
+
+func LoadConfig(props properties.Properties) Config {
+	return Config{
+		Setter:    props.GetString("visualizer.wallpaper.setter", "gsettings"),
+		Command:   props.GetString("visualizer.wallpaper.command", ""),
+		Interval:  time.Minute * time.Duration(props.GetInt("visualizer.wallpaper.interval.minutes", 30)),
+		Directory: props.GetString("visualizer.wallpaper.directory", "wallpaper"),
+		Width:     props.GetInt("visualizer.wallpaper.width", 0),
+		Height:    props.GetInt("visualizer.wallpaper.height", 0),
+	}
+}
+
+// Setter makes an image file the desktop wallpaper through the commands of one of the known setters or a custom
+// command template, e.g. visualizer.wallpaper.command=xwallpaper --zoom {path}
+type Setter struct {
+	commands    []string
+	keepRunning bool
+	running     *exec.Cmd
+}
+
+// NewSetter returns the known setter of the name, or the custom command for the setter custom.
+func NewSetter(name string, customCommand string) (*Setter, error) {
+	if name == "custom" {
+		if strings.TrimSpace(customCommand) == "" {
+			return nil, errors.New("No wallpaper command configured in visualizer.wallpaper.command")
+		}
+		return &Setter{commands: []string{customCommand}}, nil
+	}
+	commands, known := setterCommands[name]
+	if !known {
+		return nil, errors.New("Unknown wallpaper setter " + name + ", must be gsettings, feh, swaybg or custom")
+	}
+	return &Setter{commands: commands, keepRunning: keptRunning[name]}, nil
+}
+
+// Set makes the image file the wallpaper.
+func (setter *Setter) Set(path string) error {
+	uri := "file://" + path
+	for _, commandTemplate := range setter.commands {
+		// The template is split before the values are replaced so a path with spaces stays a single argument
+		var args []string
+		for _, arg := range strings.Fields(commandTemplate) {
+			arg = strings.ReplaceAll(arg, "{path}", path)
+			arg = strings.ReplaceAll(arg, "{uri}", uri)
+			args = append(args, arg)
+		}
+		command := exec.Command(args[0], args[1:]...)
+		if !setter.keepRunning {
+			output, runErr := command.CombinedOutput()
+			if runErr != nil && !optionalCommands[commandTemplate] {
+				return errors.New(commandTemplate + " failed: " + runErr.Error() + " " + strings.TrimSpace(string(output)))
+			}
+			continue
+		}
+
+		// The new setter is started before the old one is stopped, so the desktop does not flash without wallpaper
+		command.Stdout = os.Stdout
+		command.Stderr = os.Stderr
+		startErr := command.Start()
+		if startErr != nil {
+			return startErr
+		}
+		go command.Wait()
+		setter.Close()
+		setter.running = command
+	}
+	return nil
+}
+
+// Close stops a setter kept running, leaving the desktop without the wallpaper it showed.
+func (setter *Setter) Close() {
+	if setter.running != nil {
+		setter.running.Process.Kill()
+		setter.running = nil
+	}
+}
+
+var primaryOutput = regexp.MustCompile(`connected primary (\d+)x(\d+)\+`)
+var connectedOutput = regexp.MustCompile(`connected (\d+)x(\d+)\+`)
+
+// ScreenSize returns the resolution of the primary monitor as xrandr reports it, or of the first connected monitor
+// when none is primary.
+func ScreenSize() (int, int, error) {
+	output, xrandrErr := exec.Command("xrandr", "--current").Output()
+	if xrandrErr != nil {
+		return 0, 0, errors.New("Failed to run xrandr: " + xrandrErr.Error())
+	}
+	match := primaryOutput.FindStringSubmatch(string(output))
+	if match == nil {
+		match = connectedOutput.FindStringSubmatch(string(output))
+	}
+	if match == nil {
+		return 0, 0, errors.New("No connected monitor found by xrandr")
+	}
+	width, _ := strconv.Atoi(match[1])
+	height, _ := strconv.Atoi(match[2])
+	return width, height, nil
+}